@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tamboto2000/json6"
+)
+
+// lineIndex convert byte offsets of a document into LSP positions.
+// Lines are terminated by "\n", "\r\n", or "\r" like LSP defines,
+// so positions are not taken from json6.Position which also count U+2028 and U+2029
+type lineIndex struct {
+	src   []byte
+	lines []int // byte offset of the beginning of each line
+}
+
+func newLineIndex(src []byte) *lineIndex {
+	idx := &lineIndex{src: src, lines: []int{0}}
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '\r':
+			if i+1 < len(src) && src[i+1] == '\n' {
+				i++
+			}
+
+			idx.lines = append(idx.lines, i+1)
+
+		case '\n':
+			idx.lines = append(idx.lines, i+1)
+		}
+	}
+
+	return idx
+}
+
+// position convert byte offset into LSP position
+func (idx *lineIndex) position(offset int) position {
+	if offset > len(idx.src) {
+		offset = len(idx.src)
+	}
+
+	line := sort.Search(len(idx.lines), func(i int) bool { return idx.lines[i] > offset }) - 1
+	return position{Line: line, Character: utf16Len(idx.src[idx.lines[line]:offset])}
+}
+
+// span convert byte range [start, end) into LSP range
+func (idx *lineIndex) span(start, end int) lspRange {
+	return lspRange{Start: idx.position(start), End: idx.position(end)}
+}
+
+// lineLength return length of line in UTF-16 code units, without the line terminator
+func (idx *lineIndex) lineLength(line int) int {
+	end := len(idx.src)
+	if line+1 < len(idx.lines) {
+		end = idx.lines[line+1]
+	}
+
+	text := bytes.TrimRight(idx.src[idx.lines[line]:end], "\r\n")
+	return utf16Len(text)
+}
+
+// utf16Len return count of UTF-16 code units needed to encode text
+func utf16Len(text []byte) int {
+	n := 0
+	for len(text) > 0 {
+		char, size := utf8.DecodeRune(text)
+		text = text[size:]
+		n++
+		if char >= 0x10000 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// tokenize read every token of src, invalid tokens are skipped and reported by the returned lexer
+func tokenize(src []byte) ([]json6.Token, *json6.Lexer) {
+	var tokens []json6.Token
+	lx := json6.NewLexer(bytes.NewReader(src))
+	lx.IgnoreError(true)
+	for {
+		token, err := lx.Next()
+		if err != nil {
+			break
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, lx
+}
+
+// diagnostics return lexical errors of src, or the first syntax error if every token is valid
+func diagnostics(src []byte) []diagnostic {
+	idx := newLineIndex(src)
+	diags := []diagnostic{}
+	_, lx := tokenize(src)
+	for _, synErr := range lx.Diagnostics() {
+		diags = append(diags, newDiagnostic(idx, synErr))
+	}
+
+	if len(diags) > 0 {
+		return diags
+	}
+
+	var node json6.Node
+	if err := json6.Unmarshal(src, &node); err != nil {
+		var synErr *json6.SyntaxError
+		if errors.As(err, &synErr) {
+			return append(diags, newDiagnostic(idx, synErr))
+		}
+
+		return append(diags, diagnostic{Severity: severityError, Source: "json6", Message: err.Error()})
+	}
+
+	return diags
+}
+
+// newDiagnostic convert synErr into diagnostic, the range cover the token near the error if it start at the error,
+// otherwise the character at the error
+func newDiagnostic(idx *lineIndex, synErr *json6.SyntaxError) diagnostic {
+	start := synErr.Pos.Offset()
+	if start > len(idx.src) {
+		start = len(idx.src)
+	}
+
+	end := start
+	if synErr.Near != "" && bytes.HasPrefix(idx.src[start:], []byte(synErr.Near)) {
+		end += len(synErr.Near)
+	} else if start < len(idx.src) {
+		_, size := utf8.DecodeRune(idx.src[start:])
+		end += size
+	}
+
+	msg := synErr.Msg
+	if len(synErr.Expecting) > 0 {
+		msg += ", expecting " + strings.Join(synErr.Expecting, ", ")
+	}
+
+	return diagnostic{Range: idx.span(start, end), Severity: severityError, Source: "json6", Message: msg}
+}
+
+// foldingRanges return ranges of objects and arrays, and block comments, that span multiple lines.
+// The line of closing bracket is left unfolded
+func foldingRanges(src []byte) []foldingRange {
+	idx := newLineIndex(src)
+	tokens, _ := tokenize(src)
+	ranges := []foldingRange{}
+	var openLines []int
+	for _, token := range tokens {
+		switch {
+		case token.Type() == json6.TokenComment:
+			start, end := idx.position(token.StartPos.Offset()).Line, idx.position(token.EndOffset()).Line
+			if end > start {
+				ranges = append(ranges, foldingRange{StartLine: start, EndLine: end, Kind: "comment"})
+			}
+
+		case isPunct(token, "{", "["):
+			openLines = append(openLines, idx.position(token.StartPos.Offset()).Line)
+
+		case isPunct(token, "}", "]") && len(openLines) > 0:
+			start := openLines[len(openLines)-1]
+			openLines = openLines[:len(openLines)-1]
+			if end := idx.position(token.StartPos.Offset()).Line - 1; end > start {
+				ranges = append(ranges, foldingRange{StartLine: start, EndLine: end})
+			}
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].StartLine < ranges[j].StartLine })
+	return ranges
+}
+
+// isPunct check if token is one of puncts
+func isPunct(token json6.Token, puncts ...string) bool {
+	if token.Type() != json6.TokenPunctuator {
+		return false
+	}
+
+	for _, punct := range puncts {
+		if token.String() == punct {
+			return true
+		}
+	}
+
+	return false
+}
+
+// symbolParser build document symbols from tokens of a valid document
+type symbolParser struct {
+	idx    *lineIndex
+	tokens []json6.Token // tokens without comments
+	pos    int           // index of the current token
+}
+
+// documentSymbols return members of root object, or elements of root array, as symbols,
+// nested objects and arrays are children of their symbol. Invalid document has no symbol
+func documentSymbols(src []byte) []documentSymbol {
+	var node json6.Node
+	if err := json6.Unmarshal(src, &node); err != nil {
+		return []documentSymbol{}
+	}
+
+	p := &symbolParser{idx: newLineIndex(src)}
+	tokens, _ := tokenize(src)
+	for _, token := range tokens {
+		if token.Type() != json6.TokenComment {
+			p.tokens = append(p.tokens, token)
+		}
+	}
+
+	_, children, _ := p.value()
+	if children == nil {
+		children = []documentSymbol{}
+	}
+
+	return children
+}
+
+func (p *symbolParser) next() json6.Token {
+	token := p.tokens[p.pos]
+	p.pos++
+	return token
+}
+
+// value parse the value at the current token, return its symbol kind, children, and end offset
+func (p *symbolParser) value() (kind int, children []documentSymbol, end int) {
+	token := p.next()
+	switch {
+	case isPunct(token, "{"):
+		for !isPunct(p.tokens[p.pos], "}") {
+			children = append(children, p.member())
+			if isPunct(p.tokens[p.pos], ",") {
+				p.pos++
+			}
+		}
+
+		return symbolObject, children, p.next().EndOffset()
+
+	case isPunct(token, "["):
+		for i := 0; !isPunct(p.tokens[p.pos], "]"); i++ {
+			// empty element has no symbol
+			if isPunct(p.tokens[p.pos], ",") {
+				p.pos++
+				continue
+			}
+
+			start := p.tokens[p.pos].StartPos.Offset()
+			sym := p.symbol(strconv.Itoa(i), start)
+			sym.SelectionRange = sym.Range
+			children = append(children, sym)
+			if isPunct(p.tokens[p.pos], ",") {
+				p.pos++
+			}
+		}
+
+		return symbolArray, children, p.next().EndOffset()
+	}
+
+	switch token.Type() {
+	case json6.TokenString:
+		kind = symbolString
+	case json6.TokenBool:
+		kind = symbolBoolean
+	case json6.TokenNull, json6.TokenUndefined:
+		kind = symbolNull
+	default:
+		kind = symbolNumber
+	}
+
+	return kind, nil, token.EndOffset()
+}
+
+// member parse object member at the current token
+func (p *symbolParser) member() documentSymbol {
+	key := p.next()
+	p.next() // colon
+
+	// decode quoted and escaped key the same way the decoder does
+	var m map[string]interface{}
+	json6.Unmarshal([]byte("{"+key.String()+": null}"), &m)
+	name := key.String()
+	for k := range m {
+		name = k
+	}
+
+	sym := p.symbol(name, key.StartPos.Offset())
+	sym.SelectionRange = p.idx.span(key.StartPos.Offset(), key.EndOffset())
+	return sym
+}
+
+// symbol parse the value at the current token as symbol named name, started at offset start
+func (p *symbolParser) symbol(name string, start int) documentSymbol {
+	valueToken := p.tokens[p.pos]
+	kind, children, end := p.value()
+	sym := documentSymbol{Name: name, Kind: kind, Range: p.idx.span(start, end), Children: children}
+	if children == nil && kind != symbolObject && kind != symbolArray {
+		sym.Detail = valueToken.String()
+	}
+
+	return sym
+}
+
+// semantic token types, index of the type in the legend
+var semanticTokenTypes = []string{"property", "string", "number", "keyword", "comment"}
+
+const (
+	semanticProperty = iota
+	semanticString
+	semanticNumber
+	semanticKeyword
+	semanticComment
+)
+
+// semanticTokenData encode tokens of src as LSP semantic tokens, object keys are properties.
+// Token that span multiple lines, like block comment, is split into one token per line
+func semanticTokenData(src []byte) []int {
+	idx := newLineIndex(src)
+	tokens, _ := tokenize(src)
+	data := []int{}
+	prevLine, prevChar := 0, 0
+	add := func(line, char, length, tokenType int) {
+		if length == 0 {
+			return
+		}
+
+		if line != prevLine {
+			prevChar = 0
+		}
+
+		data = append(data, line-prevLine, char-prevChar, length, tokenType, 0)
+		prevLine, prevChar = line, char
+	}
+
+	for i, token := range tokens {
+		var tokenType int
+		switch token.Type() {
+		case json6.TokenIdentifier:
+			// identifier is only valid as object key, other identifiers are reported by diagnostics
+			if !isKey(tokens, i) {
+				continue
+			}
+
+			tokenType = semanticProperty
+
+		case json6.TokenString:
+			tokenType = semanticString
+			if isKey(tokens, i) {
+				tokenType = semanticProperty
+			}
+
+		case json6.TokenNumber:
+			tokenType = semanticNumber
+		case json6.TokenNull, json6.TokenBool, json6.TokenUndefined:
+			tokenType = semanticKeyword
+		case json6.TokenComment:
+			tokenType = semanticComment
+		default:
+			continue
+		}
+
+		start, end := idx.position(token.StartPos.Offset()), idx.position(token.EndOffset())
+		for line := start.Line; line <= end.Line; line++ {
+			char, lineEnd := 0, idx.lineLength(line)
+			if line == start.Line {
+				char = start.Character
+			}
+
+			if line == end.Line {
+				lineEnd = end.Character
+			}
+
+			add(line, char, lineEnd-char, tokenType)
+		}
+	}
+
+	return data
+}
+
+// isKey check if tokens[i] is followed by colon, skipping comments
+func isKey(tokens []json6.Token, i int) bool {
+	for _, token := range tokens[i+1:] {
+		if token.Type() != json6.TokenComment {
+			return isPunct(token, ":")
+		}
+	}
+
+	return false
+}
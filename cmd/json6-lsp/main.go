@@ -0,0 +1,26 @@
+// Command json6-lsp is a Language Server Protocol server for JSON6 documents.
+//
+// Usage:
+//
+//	json6-lsp
+//
+// json6-lsp communicates with the editor over standard input and output. It provides:
+//
+//	diagnostics		syntax errors, published when a document is opened or changed
+//	formatting		canonical style of json6fmt, indented by tab or by spaces as the editor requests
+//	folding ranges		multi-line objects, arrays, and block comments
+//	document symbols	object keys and array elements, nested by their values
+//	semantic tokens		keys, strings, numbers, keywords, and comments
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := newServer(os.Stdin, os.Stdout).serve(); err != nil {
+		fmt.Fprintln(os.Stderr, "json6-lsp:", err)
+		os.Exit(1)
+	}
+}
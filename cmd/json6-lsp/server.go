@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/tamboto2000/json6/internal/format"
+)
+
+// errExitWithoutShutdown is returned by serve when exit is received before shutdown
+var errExitWithoutShutdown = errors.New("exit received before shutdown")
+
+// server is a JSON6 language server, documents are synchronized in full
+type server struct {
+	r           *bufio.Reader
+	w           io.Writer
+	docs        map[string][]byte // open documents by URI
+	initialized bool              // initialize request has been received
+	shutdown    bool              // shutdown request has been received
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{
+		r:    bufio.NewReader(r),
+		w:    w,
+		docs: make(map[string][]byte),
+	}
+}
+
+// handler handle request or notification params, the result is ignored for notification
+type handler func(s *server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	"initialize":                       (*server).initialize,
+	"initialized":                      nop,
+	"shutdown":                         (*server).shutdownRequest,
+	"textDocument/didOpen":             (*server).didOpen,
+	"textDocument/didChange":           (*server).didChange,
+	"textDocument/didClose":            (*server).didClose,
+	"textDocument/formatting":          (*server).formatting,
+	"textDocument/foldingRange":        (*server).foldingRange,
+	"textDocument/documentSymbol":      (*server).documentSymbol,
+	"textDocument/semanticTokens/full": (*server).semanticTokensFull,
+}
+
+func nop(s *server, params json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// serve read and handle messages until exit notification or end of input.
+// It return errExitWithoutShutdown if exit is received before shutdown
+func (s *server) serve() error {
+	for {
+		msg, err := readMessage(s.r)
+		if err != nil {
+			var respErr *responseError
+			if errors.As(err, &respErr) {
+				if err := s.respond(json.RawMessage("null"), nil, respErr); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatch msg to its handler and respond if msg is a request
+func (s *server) handle(msg *message) error {
+	isRequest := msg.ID != nil
+	h, ok := handlers[msg.Method]
+	switch {
+	case !ok:
+		// unknown notifications, like $/cancelRequest, are ignored
+		if !isRequest {
+			return nil
+		}
+
+		return s.respond(msg.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+
+	case !s.initialized && msg.Method != "initialize":
+		if !isRequest {
+			return nil
+		}
+
+		return s.respond(msg.ID, nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"})
+	}
+
+	result, err := h(s, msg.Params)
+	if !isRequest {
+		return err
+	}
+
+	if err != nil {
+		var respErr *responseError
+		if !errors.As(err, &respErr) {
+			respErr = &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+
+		return s.respond(msg.ID, nil, respErr)
+	}
+
+	return s.respond(msg.ID, result, nil)
+}
+
+// respond write response of request id
+func (s *server) respond(id json.RawMessage, result interface{}, respErr *responseError) error {
+	msg := &message{ID: id, Error: respErr}
+	if respErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		msg.Result = raw
+	}
+
+	return writeMessage(s.w, msg)
+}
+
+// notify write notification
+func (s *server) notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return writeMessage(s.w, &message{Method: method, Params: raw})
+}
+
+// publishDiagnostics publish diagnostics of document uri
+func (s *server) publishDiagnostics(uri string) error {
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics(s.docs[uri]),
+	})
+}
+
+// document return open document of uri
+func (s *server) document(uri string) ([]byte, error) {
+	src, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: "document is not open: " + uri}
+	}
+
+	return src, nil
+}
+
+func (s *server) initialize(params json.RawMessage) (interface{}, error) {
+	if s.initialized {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server already initialized"}
+	}
+
+	s.initialized = true
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    1, // full
+			},
+			"documentFormattingProvider": true,
+			"foldingRangeProvider":       true,
+			"documentSymbolProvider":     true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{
+					"tokenTypes":     semanticTokenTypes,
+					"tokenModifiers": []string{},
+				},
+				"full": true,
+			},
+		},
+		"serverInfo": map[string]string{"name": "json6-lsp"},
+	}, nil
+}
+
+func (s *server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p didOpenParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.docs[p.TextDocument.URI] = []byte(p.TextDocument.Text)
+	return nil, s.publishDiagnostics(p.TextDocument.URI)
+}
+
+func (s *server) didChange(params json.RawMessage) (interface{}, error) {
+	var p didChangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	// with full synchronization, the last change is the whole document
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	s.docs[p.TextDocument.URI] = []byte(p.ContentChanges[len(p.ContentChanges)-1].Text)
+	return nil, s.publishDiagnostics(p.TextDocument.URI)
+}
+
+func (s *server) didClose(params json.RawMessage) (interface{}, error) {
+	var p didCloseParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	delete(s.docs, p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// formatting replace the whole document with its formatted source, invalid document is left as is
+func (s *server) formatting(params json.RawMessage) (interface{}, error) {
+	var p formattingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	src, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	style := format.Style{Indent: "\t", Quote: '\'', TrailingComma: true}
+	if p.Options.InsertSpaces {
+		style.Indent = strings.Repeat(" ", p.Options.TabSize)
+	}
+
+	formatted, err := format.Format(src, style)
+	if err != nil || string(formatted) == string(src) {
+		return []textEdit{}, nil
+	}
+
+	return []textEdit{{Range: newLineIndex(src).span(0, len(src)), NewText: string(formatted)}}, nil
+}
+
+func (s *server) foldingRange(params json.RawMessage) (interface{}, error) {
+	src, err := s.documentOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return foldingRanges(src), nil
+}
+
+func (s *server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	src, err := s.documentOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return documentSymbols(src), nil
+}
+
+func (s *server) semanticTokensFull(params json.RawMessage) (interface{}, error) {
+	src, err := s.documentOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return semanticTokens{Data: semanticTokenData(src)}, nil
+}
+
+// documentOf return open document of documentParams params
+func (s *server) documentOf(params json.RawMessage) ([]byte, error) {
+	var p documentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	return s.document(p.TextDocument.URI)
+}
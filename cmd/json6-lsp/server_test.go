@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testClient is an in-process LSP client connected to a server by pipes
+type testClient struct {
+	t             *testing.T
+	w             io.WriteCloser
+	msgs          chan *message
+	notifications []*message
+	nextID        int
+	done          chan error // result of server.serve
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+	c := &testClient{t: t, w: clientW, msgs: make(chan *message), done: make(chan error, 1)}
+	go func() {
+		c.done <- newServer(serverR, serverW).serve()
+		serverW.Close()
+	}()
+
+	// messages are read concurrently, so the server is never blocked writing notifications
+	go func() {
+		r := bufio.NewReader(clientR)
+		for {
+			msg, err := readMessage(r)
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+
+			c.msgs <- msg
+		}
+	}()
+
+	return c
+}
+
+func (c *testClient) write(msg *message) {
+	if err := writeMessage(c.w, msg); err != nil {
+		c.t.Fatal(err.Error())
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	raw, _ := json.Marshal(params)
+	c.write(&message{Method: method, Params: raw})
+}
+
+// call send request and decode its result into result, notifications received meanwhile are kept
+func (c *testClient) call(method string, params interface{}, result interface{}) *responseError {
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	raw, _ := json.Marshal(params)
+	c.write(&message{ID: id, Method: method, Params: raw})
+	for msg := range c.msgs {
+		if msg.Method != "" {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+
+		if string(msg.ID) != string(id) {
+			c.t.Fatalf("unexpected response id %s, expecting %s", msg.ID, id)
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatal(err.Error())
+		}
+
+		return nil
+	}
+
+	c.t.Fatal("server closed the connection")
+	return nil
+}
+
+// diagnostics return the last diagnostics published for uri
+func (c *testClient) diagnostics(uri string) []diagnostic {
+	for i := len(c.notifications) - 1; i >= 0; i-- {
+		var p publishDiagnosticsParams
+		json.Unmarshal(c.notifications[i].Params, &p)
+		if c.notifications[i].Method == "textDocument/publishDiagnostics" && p.URI == uri {
+			return p.Diagnostics
+		}
+	}
+
+	c.t.Fatalf("no diagnostics published for %s", uri)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	c := newTestClient(t)
+	var null interface{}
+	if err := c.call("textDocument/foldingRange", documentParams{}, &null); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("unexpected %v, expecting server not initialized error", err)
+	}
+
+	var initResult struct {
+		Capabilities struct {
+			SemanticTokensProvider struct {
+				Legend struct {
+					TokenTypes []string `json:"tokenTypes"`
+				} `json:"legend"`
+			} `json:"semanticTokensProvider"`
+		} `json:"capabilities"`
+	}
+
+	if err := c.call("initialize", map[string]interface{}{}, &initResult); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if tokenTypes := initResult.Capabilities.SemanticTokensProvider.Legend.TokenTypes; !reflect.DeepEqual(tokenTypes, semanticTokenTypes) {
+		t.Errorf("unexpected token types %v, expecting %v", tokenTypes, semanticTokenTypes)
+	}
+
+	c.notify("initialized", struct{}{})
+
+	// diagnostics
+	uri := "file:///config.json6"
+	c.notify("textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Text: "{\n\tname: 'a😀' 1,\n}"}})
+	if err := c.call("textDocument/documentSymbol", documentParams{TextDocument: textDocumentIdentifier{URI: uri}}, &null); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	diags := c.diagnostics(uri)
+	expectedRange := lspRange{Start: position{Line: 1, Character: 13}, End: position{Line: 1, Character: 14}}
+	if len(diags) != 1 || diags[0].Range != expectedRange || diags[0].Severity != severityError {
+		t.Errorf("unexpected diagnostics %+v, expecting one error at %+v", diags, expectedRange)
+	}
+
+	src := `// config
+{
+	name: "app", /* first
+	second */
+	ports: [80, 443],
+	db: {"host": 'localhost'},
+}`
+	c.notify("textDocument/didChange", didChangeParams{
+		TextDocument: textDocumentIdentifier{URI: uri},
+		ContentChanges: []struct {
+			Text string `json:"text"`
+		}{{Text: src}},
+	})
+
+	// symbols
+	var symbols []documentSymbol
+	if err := c.call("textDocument/documentSymbol", documentParams{TextDocument: textDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if diags := c.diagnostics(uri); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics %+v, expecting none", diags)
+	}
+
+	var names []string
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
+	}
+
+	if expected := []string{"name", "ports", "db"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected symbols %v, expecting %v", names, expected)
+	}
+
+	if sym := symbols[0]; sym.Kind != symbolString || sym.Detail != `"app"` ||
+		sym.SelectionRange != (lspRange{Start: position{Line: 2, Character: 1}, End: position{Line: 2, Character: 5}}) {
+		t.Errorf("unexpected symbol %+v", sym)
+	}
+
+	if sym := symbols[1]; sym.Kind != symbolArray || len(sym.Children) != 2 || sym.Children[1].Name != "1" || sym.Children[1].Kind != symbolNumber {
+		t.Errorf("unexpected symbol %+v", sym)
+	}
+
+	if sym := symbols[2]; sym.Kind != symbolObject || len(sym.Children) != 1 || sym.Children[0].Name != "host" ||
+		sym.Range != (lspRange{Start: position{Line: 5, Character: 1}, End: position{Line: 5, Character: 26}}) {
+		t.Errorf("unexpected symbol %+v", sym)
+	}
+
+	// folding ranges
+	var ranges []foldingRange
+	if err := c.call("textDocument/foldingRange", documentParams{TextDocument: textDocumentIdentifier{URI: uri}}, &ranges); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expectedRanges := []foldingRange{{StartLine: 1, EndLine: 5}, {StartLine: 2, EndLine: 3, Kind: "comment"}}
+	if !reflect.DeepEqual(ranges, expectedRanges) {
+		t.Errorf("unexpected folding ranges %+v, expecting %+v", ranges, expectedRanges)
+	}
+
+	// semantic tokens
+	var tokens semanticTokens
+	if err := c.call("textDocument/semanticTokens/full", documentParams{TextDocument: textDocumentIdentifier{URI: uri}}, &tokens); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expectedData := []int{
+		0, 0, 9, semanticComment, 0, // config
+		2, 1, 4, semanticProperty, 0, // name
+		0, 6, 5, semanticString, 0, // "app"
+		0, 7, 8, semanticComment, 0, // /* first
+		1, 0, 10, semanticComment, 0, // second */
+		1, 1, 5, semanticProperty, 0, // ports
+		0, 8, 2, semanticNumber, 0, // 80
+		0, 4, 3, semanticNumber, 0, // 443
+		1, 1, 2, semanticProperty, 0, // db
+		0, 5, 6, semanticProperty, 0, // "host"
+		0, 8, 11, semanticString, 0, // 'localhost'
+	}
+
+	if !reflect.DeepEqual(tokens.Data, expectedData) {
+		t.Errorf("unexpected semantic tokens %v, expecting %v", tokens.Data, expectedData)
+	}
+
+	// formatting
+	var edits []textEdit
+	params := formattingParams{TextDocument: textDocumentIdentifier{URI: uri}}
+	params.Options.TabSize = 2
+	params.Options.InsertSpaces = true
+	if err := c.call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expectedText := `// config
+{
+  name: 'app', /* first
+	second */
+  ports: [
+    80,
+    443,
+  ],
+  db: {
+    host: 'localhost',
+  },
+}
+`
+	if len(edits) != 1 || edits[0].NewText != expectedText ||
+		edits[0].Range != (lspRange{End: position{Line: 6, Character: 1}}) {
+		t.Errorf("unexpected edits %+v, expecting %q", edits, expectedText)
+	}
+
+	if err := c.call("textDocument/hover", documentParams{}, &null); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unexpected %v, expecting method not found error", err)
+	}
+
+	c.notify("textDocument/didClose", didCloseParams{TextDocument: textDocumentIdentifier{URI: uri}})
+	if err := c.call("shutdown", nil, &null); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if diags := c.diagnostics(uri); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics %+v after close, expecting none", diags)
+	}
+
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error %s on exit", err.Error())
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	c := newTestClient(t)
+	c.notify("exit", nil)
+	if err := <-c.done; err != errExitWithoutShutdown {
+		t.Errorf("unexpected %v, expecting %v", err, errExitWithoutShutdown)
+	}
+}
+
+func TestServerInvalidContentLength(t *testing.T) {
+	for _, length := range []string{"-1", "1099511627776", "x"} {
+		c := newTestClient(t)
+		go io.WriteString(c.w, "Content-Length: "+length+"\r\n\r\n{}")
+		if err := <-c.done; err == nil || !strings.Contains(err.Error(), "invalid Content-Length") {
+			t.Errorf("unexpected %v for Content-Length %s, expecting invalid Content-Length error", err, length)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Command json6 is a tool for working with JSON6 documents.
+//
+// Usage:
+//
+//	json6 <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	tojson	convert JSON6 document into strict JSON
+//
+// Use "json6 <command> -h" for flags of a command.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a subcommand of json6
+type command struct {
+	short string                  // short description, shown in usage
+	run   func(args []string) int // run the command with its arguments, return exit code
+}
+
+var commands = map[string]command{
+	"tojson": {short: "convert JSON6 document into strict JSON", run: runToJSON},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: json6 <command> [flags] [arguments]\n\nThe commands are:\n\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", name, commands[name].short)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nUse \"json6 <command> -h\" for flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "json6: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(cmd.run(os.Args[2:]))
+}
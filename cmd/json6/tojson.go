@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tamboto2000/json6"
+)
+
+// runToJSON convert JSON6 file, or standard input if no file is given, into strict JSON.
+// See json6.ToJSON for how values without JSON equivalent are handled
+func runToJSON(args []string) int {
+	flags := flag.NewFlagSet("tojson", flag.ContinueOnError)
+	indent := flags.String("indent", "", "indentation of each nesting level, the output is compact if empty")
+	output := flags.String("o", "", "write the output to file instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: json6 tojson [flags] [file]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var src []byte
+	var err error
+	name := "<standard input>"
+	if flags.NArg() == 1 {
+		name = flags.Arg(0)
+		src, err = ioutil.ReadFile(name)
+	} else {
+		src, err = ioutil.ReadAll(os.Stdin)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	res, err := toJSON(src, *indent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err.Error())
+		return 1
+	}
+
+	if *output != "" {
+		err = ioutil.WriteFile(*output, res, 0644)
+	} else {
+		_, err = os.Stdout.Write(res)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// toJSON convert src into JSON indented by indent, followed by a newline
+func toJSON(src []byte, indent string) ([]byte, error) {
+	res, err := json6.ToJSON(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if indent != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, res, "", indent); err != nil {
+			return nil, err
+		}
+
+		res = buf.Bytes()
+	}
+
+	return append(res, '\n'), nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	src := `{name: 'JSON6', keywords: ['json', 'es6',], /* none */ deps: {}}`
+	expected := `{
+  "name": "JSON6",
+  "keywords": [
+    "json",
+    "es6"
+  ],
+  "deps": {}
+}
+`
+
+	res, err := toJSON([]byte(src), "  ")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+
+	res, err = toJSON([]byte(src), "")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if expected := "{\"name\":\"JSON6\",\"keywords\":[\"json\",\"es6\"],\"deps\":{}}\n"; string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+}
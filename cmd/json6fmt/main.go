@@ -0,0 +1,223 @@
+// Command json6fmt formats JSON6 documents in a canonical style.
+//
+// Usage:
+//
+//	json6fmt [flags] [path ...]
+//
+// Without path, json6fmt reads from standard input and writes to standard output.
+// A directory path is walked for files with .json6 extension.
+// Comments are kept, and blank lines between members are collapsed into one.
+//
+// The flags are:
+//
+//	-l
+//		list files whose formatting differs from json6fmt's
+//	-w
+//		write result to the source file instead of standard output
+//	-d
+//		display diffs instead of rewriting files
+//	-indent string
+//		indentation of each nesting level (default tab)
+//	-quote string
+//		quote character of strings and quoted keys, one of " ' ` (default ')
+//	-trailing-comma
+//		write comma after the last member of multi-line object and array (default true)
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tamboto2000/json6/internal/format"
+)
+
+var (
+	list          = flag.Bool("l", false, "list files whose formatting differs from json6fmt's")
+	write         = flag.Bool("w", false, "write result to the source file instead of standard output")
+	doDiff        = flag.Bool("d", false, "display diffs instead of rewriting files")
+	indent        = flag.String("indent", "\t", "indentation of each nesting level")
+	quote         = flag.String("quote", "'", "quote character of strings and quoted keys, one of \" ' `")
+	trailingComma = flag.Bool("trailing-comma", true, "write comma after the last member of multi-line object and array")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: json6fmt [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	s, err := parseStyle()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "error: cannot use -w with standard input")
+			os.Exit(2)
+		}
+
+		if err := processFile("<standard input>", os.Stdin, os.Stdout, s); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+
+		return
+	}
+
+	exitCode := 0
+	for _, root := range flag.Args() {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// files given explicitly are always formatted, files in directories only if they are JSON6
+			if info.IsDir() || (path != root && !isJSON6File(path, info)) {
+				return nil
+			}
+
+			if err := processFile(path, nil, os.Stdout, s); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				exitCode = 2
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			exitCode = 2
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
+func parseStyle() (format.Style, error) {
+	s := format.Style{Indent: *indent, TrailingComma: *trailingComma}
+	switch *quote {
+	case `"`, "'", "`":
+		s.Quote = rune((*quote)[0])
+
+	default:
+		return s, fmt.Errorf("error: invalid quote %q, must be one of \" ' `", *quote)
+	}
+
+	return s, nil
+}
+
+func isJSON6File(path string, info os.FileInfo) bool {
+	return !strings.HasPrefix(info.Name(), ".") && filepath.Ext(path) == ".json6"
+}
+
+// processFile format file at path, or in if it is not nil, and write the result to out
+// according to -l, -w, and -d flags
+func processFile(path string, in io.Reader, out io.Writer, s format.Style) error {
+	var src []byte
+	var err error
+	if in != nil {
+		src, err = ioutil.ReadAll(in)
+	} else {
+		src, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	res, err := format.Format(src, s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if !*list && !*write && !*doDiff {
+		_, err = out.Write(res)
+		return err
+	}
+
+	if bytes.Equal(src, res) {
+		return nil
+	}
+
+	if *list {
+		fmt.Fprintln(out, path)
+	}
+
+	if *write {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(path, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if *doDiff {
+		d, err := diff(path, src, res)
+		if err != nil {
+			return fmt.Errorf("computing diff: %w", err)
+		}
+
+		out.Write(d)
+	}
+
+	return nil
+}
+
+// diff run diff -u on original and formatted source
+func diff(path string, src, res []byte) ([]byte, error) {
+	f1, err := writeTempFile("json6fmt", src)
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("json6fmt", res)
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f2)
+
+	d, err := exec.Command("diff", "-u", "--label", path+".orig", "--label", path, f1, f2).Output()
+	if len(d) > 0 {
+		// diff exits with status 1 if the files differ
+		return d, nil
+	}
+
+	return d, err
+}
+
+func writeTempFile(prefix string, data []byte) (string, error) {
+	file, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
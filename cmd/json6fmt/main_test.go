@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tamboto2000/json6/internal/format"
+)
+
+const (
+	unformatted = "{a:1,b:[2]}"
+	formatted   = "{\n\ta: 1,\n\tb: [\n\t\t2,\n\t],\n}\n"
+)
+
+var style = format.Style{Indent: "\t", Quote: '\'', TrailingComma: true}
+
+// setFlags set -l, -w, and -d flags until the test ends
+func setFlags(t *testing.T, l, w, d bool) {
+	oldList, oldWrite, oldDiff := *list, *write, *doDiff
+	*list, *write, *doDiff = l, w, d
+	t.Cleanup(func() {
+		*list, *write, *doDiff = oldList, oldWrite, oldDiff
+	})
+}
+
+// writeFile write src to a new file in a temporary directory and return its path
+func writeFile(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "config.json6")
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return string(src)
+}
+
+func TestProcessFile(t *testing.T) {
+	setFlags(t, false, false, false)
+	var out bytes.Buffer
+	if err := processFile("<standard input>", strings.NewReader(unformatted), &out, style); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if out.String() != formatted {
+		t.Errorf("unexpected %q, expecting %q", out.String(), formatted)
+	}
+
+	if err := processFile("<standard input>", strings.NewReader("{a: }"), &out, style); err == nil || !strings.HasPrefix(err.Error(), "<standard input>: ") {
+		t.Errorf("unexpected %v, expecting syntax error prefixed by path", err)
+	}
+}
+
+func TestProcessFileList(t *testing.T) {
+	setFlags(t, true, false, false)
+	path := writeFile(t, unformatted)
+	var out bytes.Buffer
+	if err := processFile(path, nil, &out, style); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if out.String() != path+"\n" {
+		t.Errorf("unexpected %q, expecting %q", out.String(), path+"\n")
+	}
+
+	if src := readFile(t, path); src != unformatted {
+		t.Errorf("unexpected %q, expecting file to be unchanged", src)
+	}
+
+	// formatted file is not listed
+	out.Reset()
+	path = writeFile(t, formatted)
+	if err := processFile(path, nil, &out, style); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("unexpected %q, expecting no output", out.String())
+	}
+}
+
+func TestProcessFileWrite(t *testing.T) {
+	setFlags(t, false, true, false)
+	path := writeFile(t, unformatted)
+	var out bytes.Buffer
+	if err := processFile(path, nil, &out, style); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("unexpected %q, expecting no output", out.String())
+	}
+
+	if src := readFile(t, path); src != formatted {
+		t.Errorf("unexpected %q, expecting %q", src, formatted)
+	}
+}
+
+func TestProcessFileDiff(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff is not installed")
+	}
+
+	setFlags(t, false, false, true)
+	path := writeFile(t, unformatted)
+	var out bytes.Buffer
+	if err := processFile(path, nil, &out, style); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	d := out.String()
+	for _, expected := range []string{"--- " + path + ".orig", "+++ " + path, "-" + unformatted, "+\ta: 1,"} {
+		if !strings.Contains(d, expected) {
+			t.Errorf("unexpected diff %q, expecting it to contain %q", d, expected)
+		}
+	}
+
+	if src := readFile(t, path); src != unformatted {
+		t.Errorf("unexpected %q, expecting file to be unchanged", src)
+	}
+}
@@ -2,12 +2,25 @@ package json6
 
 import (
 	"bytes"
-	"fmt"
+	"encoding"
+	"encoding/json"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
+// Unmarshaler is the interface implemented by types that can unmarshal a JSON6 description of themselves.
+// The input is the source text of a single JSON6 value, comments and whitespace inside object and array are kept
+type Unmarshaler interface {
+	UnmarshalJSON6([]byte) error
+}
+
 // valueType define value type of a JSON6 value
 type valueType uint
 
@@ -21,55 +34,110 @@ const (
 	valueNull
 	valueBoolean
 	valueUndefined
+	valueHole // empty array element
 )
 
 // value contains decoded value from token or sequence of tokens (like array and objects)
 type value struct {
 	t        valueType
-	rnReader *runeReader
+	pos      *Position   // position of the first token of the value
+	rnReader *runeReader // characters of the token, nil for object, array, and empty array element
+	start    int         // byte offset of the first token of the value
+	end      int         // byte offset right after the last token of the value
 	strVal   string
 	intVal   int64
+	bigVal   *big.Int // if t == valueInteger and the integer is out of int64 range, intVal is zero
 	floatVal float64
+	overflow bool // if t == valueDouble and the double is out of float64 range, floatVal is infinity
 	boolVal  bool
-	objVal   map[string]value // if t == ValueObject
-	arrVal   []value          // if t == ValueArray
+	objVal   map[string]value     // if t == ValueObject
+	keyPos   map[string]*Position // position of object keys, if t == ValueObject
+	keys     []string             // object keys in order of first appearance, if t == ValueObject
+	arrVal   []value              // if t == ValueArray
 }
 
-// getVal get value based on value.t
-func getVal(val value) reflect.Value {
+// getVal get value based on value.t, numbers are Number if dec.useNumber is true,
+// otherwise integer is int64, or *big.Int if it is out of int64 range, and double is float64.
+// Double out of float64 range can only be decoded as Number
+func (dec *decoder) getVal(val value) (reflect.Value, error) {
 	switch val.t {
 	case valueString:
-		return reflect.ValueOf(val.strVal)
-
-	case valueInteger:
-		return reflect.ValueOf(val.intVal)
+		return reflect.ValueOf(val.strVal), nil
+
+	case valueInteger, valueDouble:
+		switch {
+		case dec.useNumber:
+			return reflect.ValueOf(numberLiteral(&val)), nil
+		case val.bigVal != nil:
+			return reflect.ValueOf(new(big.Int).Set(val.bigVal)), nil
+		case val.t == valueInteger:
+			return reflect.ValueOf(val.intVal), nil
+		case val.overflow:
+			return reflect.Value{}, dec.setErrPath(errMismatchType(&val, float64Type))
+		}
 
-	case valueDouble:
-		return reflect.ValueOf(val.floatVal)
+		return reflect.ValueOf(val.floatVal), nil
 
 	case valueBoolean:
-		return reflect.ValueOf(val.boolVal)
+		return reflect.ValueOf(val.boolVal), nil
 
 	case valueObject:
 		m := make(map[string]interface{})
-		for k, v := range val.objVal {
-			m[k] = getVal(v).Interface()
+		for _, k := range val.keys {
+			v := val.objVal[k]
+			dec.path = append(dec.path, k)
+			elem, err := dec.getVal(v)
+			dec.path = dec.path[:len(dec.path)-1]
+			if err != nil {
+				return elem, err
+			}
+
+			m[k] = elem.Interface()
 		}
 
-		return reflect.ValueOf(m)
+		return reflect.ValueOf(m), nil
 
 	case valueArray:
-		arr := make([]interface{}, 0)
-		for _, v := range val.arrVal {
-			arr = append(arr, getVal(v).Interface())
+		arr, err := dec.getElems(val.arrVal)
+		if arr == nil {
+			arr = make([]interface{}, 0)
+		}
+
+		return reflect.ValueOf(arr), err
+
+	case valueUndefined:
+		return reflect.ValueOf(Undefined), nil
+
+	case valueHole:
+		return reflect.ValueOf(Hole), nil
+	}
+
+	// null, use nil interface{} so it's safe to call Interface()
+	return reflect.Zero(interfaceType), nil
+}
+
+// getElems get values of array elements, like getVal
+func (dec *decoder) getElems(vals []value) ([]interface{}, error) {
+	var arr []interface{}
+	for i, v := range vals {
+		dec.path = append(dec.path, i)
+		elem, err := dec.getVal(v)
+		dec.path = dec.path[:len(dec.path)-1]
+		if err != nil {
+			return nil, err
 		}
 
-		return reflect.ValueOf(arr)
+		arr = append(arr, elem.Interface())
 	}
 
-	return reflect.ValueOf(nil)
+	return arr, nil
 }
 
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	float64Type   = reflect.TypeOf(float64(0))
+)
+
 // getValTypeStr get value type in string
 func getValTypeStr(val value) string {
 	switch val.t {
@@ -96,11 +164,136 @@ func getValTypeStr(val value) string {
 
 	case valueUndefined:
 		return "undefined"
+
+	case valueHole:
+		return "empty array element"
 	}
 
 	return ""
 }
 
+// valToJSON convert val into strict JSON, null, undefined, and empty array elements are converted to null
+func valToJSON(val *value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, val, true); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToJSON convert JSON6 text src into strict JSON.
+// Comments are removed, object keys keep their order, numbers are written in decimal,
+// strings are re-quoted with double quote, undefined and empty array elements are converted to null,
+// and NaN and Infinity are rejected because JSON can not represent them
+func ToJSON(src []byte) ([]byte, error) {
+	dec := &decoder{lx: NewLexer(bytes.NewReader(src))}
+	if err := dec.readValue(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, &dec.val, false); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeJSONString write str as JSON string to buf, without escaping HTML characters like json.Marshal
+func writeJSONString(buf *bytes.Buffer, str string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(str); err != nil {
+		return err
+	}
+
+	// Encode terminate the value with newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// writeJSON write val as strict JSON to buf, object keys are sorted if sortKeys is true,
+// otherwise they are written in their order in source
+func writeJSON(buf *bytes.Buffer, val *value, sortKeys bool) error {
+	switch val.t {
+	case valueString:
+		if err := writeJSONString(buf, val.strVal); err != nil {
+			return err
+		}
+
+	case valueInteger:
+		if val.bigVal != nil {
+			buf.WriteString(val.bigVal.String())
+		} else {
+			buf.WriteString(strconv.FormatInt(val.intVal, 10))
+		}
+
+	case valueDouble:
+		// JSON has no range limit, write the exact value of the literal
+		if val.overflow {
+			f, _ := new(big.Float).SetString(string(numberLiteral(val)))
+			buf.WriteString(f.Text('g', -1))
+			return nil
+		}
+
+		if math.IsNaN(val.floatVal) || math.IsInf(val.floatVal, 0) {
+			return errInvalidJSONNumber(val.rnReader.chars, val.pos)
+		}
+
+		buf.WriteString(strconv.FormatFloat(val.floatVal, 'g', -1, 64))
+
+	case valueBoolean:
+		buf.WriteString(strconv.FormatBool(val.boolVal))
+
+	case valueObject:
+		keys := val.keys
+		if sortKeys {
+			keys = make([]string, len(val.keys))
+			copy(keys, val.keys)
+			sort.Strings(keys)
+		}
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeJSONString(buf, k); err != nil {
+				return err
+			}
+
+			buf.WriteByte(':')
+			v := val.objVal[k]
+			if err := writeJSON(buf, &v, sortKeys); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte('}')
+
+	case valueArray:
+		buf.WriteByte('[')
+		for i := range val.arrVal {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeJSON(buf, &val.arrVal[i], sortKeys); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+
+	default:
+		buf.WriteString("null")
+	}
+
+	return nil
+}
+
 // expectState determine what token is to be expected
 type expectState uint
 
@@ -119,21 +312,135 @@ const (
 	expectCommentOrEOF // comment, EOF
 )
 
+// maxNestingDepth is the maximum depth of nested objects and arrays,
+// deeper input is rejected before it can exhaust the stack
+const maxNestingDepth = 10000
+
+// DuplicateKeyPolicy determine how a repeated key in an object is handled
+type DuplicateKeyPolicy uint
+
+// duplicate key policies
+const (
+	DuplicateKeysLastWins  DuplicateKeyPolicy = iota // the last value overwrite earlier values, the default
+	DuplicateKeysFirstWins                           // the first value is kept, later values are ignored
+	DuplicateKeysReject                              // decoding fails with *DuplicateKeyError
+)
+
+// ArrayHolePolicy determine how an empty array element, like the second element of [1,,3], is handled
+type ArrayHolePolicy uint
+
+// array hole policies
+const (
+	ArrayHolesKeep   ArrayHolePolicy = iota // decoded as Hole in interface{}, KindHole in Node, and zero value otherwise, the default
+	ArrayHolesZero                          // decoded like null
+	ArrayHolesSkip                          // removed from the array, so the array is shorter
+	ArrayHolesReject                        // decoding fails with *ArrayHoleError
+)
+
+// LoneSurrogatePolicy determine how an escaped UTF-16 surrogate without its pair, like "\uD83D", is handled.
+// Surrogate pairs, like "\uD83D\uDE00", are always combined into one code point
+type LoneSurrogatePolicy uint
+
+// lone surrogate policies
+const (
+	LoneSurrogatesReplace LoneSurrogatePolicy = iota // replaced by U+FFFD, the default
+	LoneSurrogatesReject                             // decoding fails with *SyntaxError
+)
+
 // decoder decode tokens into JSON6 value
 type decoder struct {
-	lx     *Lexer
-	refVal reflect.Value
-	val    value
+	lx                    *Lexer
+	refVal                reflect.Value
+	val                   value
+	depth                 int                 // current depth of nested objects and arrays
+	caseSensitive         bool                // set to true to match object key to struct field by exact name only
+	disallowUnknownFields bool                // set to true to return error on object key without matching struct field
+	duplicateKeys         DuplicateKeyPolicy  // how repeated object key is handled
+	arrayHoles            ArrayHolePolicy     // how empty array element is handled
+	loneSurrogates        LoneSurrogatePolicy // how escaped UTF-16 surrogate without its pair is handled
+	path                  []interface{}       // path to the value being assigned, object key (string) or array index (int)
+	useNumber             bool                // set to true to decode numbers into interface{} as Number
+	src                   *sourceBuffer       // source of the values being decoded, nil if raw source is not needed
 }
 
-// newDecoderFromBytes initiate new decoder from []byte
-func newDecoderFromBytes(byts []byte, val interface{}) (*decoder, error) {
-	r := newReader(bytes.NewReader(byts), newPosition(1, 0))
-	lx := NewLexer(r)
-	if err := lx.FetchTokens(); err != nil {
-		return nil, err
+// raw return source text of val, object and array are sliced from dec.src
+// so their characters are not copied while decoding
+func (dec *decoder) raw(val *value) []byte {
+	if val.rnReader != nil {
+		return []byte(string(val.rnReader.chars))
+	}
+
+	if dec.src == nil {
+		return nil
+	}
+
+	return dec.src.slice(val.start, val.end)
+}
+
+// pathString format dec.path as JSON path like "$.servers[0].host",
+// key that is not a valid identifier is written like "$['not ident']"
+func (dec *decoder) pathString() string {
+	path := strings.Builder{}
+	path.WriteByte('$')
+	for _, elem := range dec.path {
+		switch elem := elem.(type) {
+		case string:
+			if isValidIdentifier(elem) {
+				path.WriteString("." + elem)
+				continue
+			}
+
+			e := &encodeState{quote: '\''}
+			e.writeString(elem)
+			path.WriteString("[" + e.String() + "]")
+
+		case int:
+			path.WriteString("[" + strconv.Itoa(elem) + "]")
+		}
+	}
+
+	return path.String()
+}
+
+// setErrPath set path of err to the current path, if err is *UnmarshalTypeError without path
+func (dec *decoder) setErrPath(err error) error {
+	if typeErr, ok := err.(*UnmarshalTypeError); ok && typeErr.Path == "" {
+		typeErr.Path = dec.pathString()
+	}
+
+	return err
+}
+
+// assignElem assign val to refVal as element of current value, at key or index pathElem
+func (dec *decoder) assignElem(refVal reflect.Value, val *value, pathElem interface{}) error {
+	dec.path = append(dec.path, pathElem)
+	err := dec.assignValue(refVal, val)
+	dec.path = dec.path[:len(dec.path)-1]
+
+	return err
+}
+
+// assignField assign val to struct field refVal, at key,
+// val is decoded from string first if quoted is true (field with ",string" tag option)
+func (dec *decoder) assignField(refVal reflect.Value, val *value, key string, quoted bool) error {
+	if !quoted || val.t != valueString {
+		return dec.assignElem(refVal, val, key)
+	}
+
+	dec.path = append(dec.path, key)
+	defer func() { dec.path = dec.path[:len(dec.path)-1] }()
+
+	quotedVal, err := dec.decodeQuotedValue(val, refVal.Type())
+	if err != nil {
+		return dec.setErrPath(err)
 	}
 
+	return dec.assignValue(refVal, &quotedVal)
+}
+
+// newDecoderFromBytes initiate new decoder from []byte
+func newDecoderFromBytes(byts []byte, val interface{}) (*decoder, error) {
+	lx := NewLexer(bytes.NewReader(byts))
 	refVal, err := valToReflect(val)
 	if err != nil {
 		return nil, err
@@ -142,6 +449,7 @@ func newDecoderFromBytes(byts []byte, val interface{}) (*decoder, error) {
 	return &decoder{
 		lx:     lx,
 		refVal: refVal,
+		src:    &sourceBuffer{buf: byts, capture: true},
 	}, nil
 }
 
@@ -164,17 +472,65 @@ func valToReflect(v interface{}) (reflect.Value, error) {
 		return val, errDecodeToNilPtr()
 	}
 
-	val = indirect(val, false)
-
 	return val, nil
 }
 
-func assignValue(refVal reflect.Value, val *value) error {
+func (dec *decoder) assignValue(refVal reflect.Value, val *value) error {
+	return dec.setErrPath(dec.assignValueAt(refVal, val))
+}
+
+func (dec *decoder) assignValueAt(refVal reflect.Value, val *value) error {
+	decodingNull := val.t == valueNull || val.t == valueUndefined || val.t == valueHole
+	u, ju, tu, pv := indirect(refVal, decodingNull)
+	if u != nil {
+		return u.UnmarshalJSON6(dec.raw(val))
+	}
+
+	if ju != nil {
+		if b, ok := ju.(*big.Int); ok && isNumberValue(val) {
+			return assignBigInt(b, val)
+		}
+
+		byts, err := valToJSON(val)
+		if err != nil {
+			return err
+		}
+
+		return ju.UnmarshalJSON(byts)
+	}
+
+	if tu != nil {
+		if f, ok := tu.(*big.Float); ok && isNumberValue(val) {
+			return assignBigFloat(f, val)
+		}
+
+		if val.t != valueString {
+			return errMismatchType(val, refVal.Type())
+		}
+
+		return tu.UnmarshalText([]byte(val.strVal))
+	}
+
+	refVal = pv
+	if refVal.Type() == nodeType {
+		refVal.Set(reflect.ValueOf(*dec.newNode(val)))
+		return nil
+	}
+
+	if refVal.Type() == fieldType {
+		return dec.assignPresenceField(refVal, val)
+	}
+
+	if isNumberValue(val) && (refVal.Type() == numberType || (dec.useNumber && refVal.Kind() == reflect.Interface)) {
+		refVal.Set(reflect.ValueOf(numberLiteral(val)).Convert(refVal.Type()))
+		return nil
+	}
+
 	switch val.t {
 	case valueObject:
-		return assignObjectValue(refVal, val)
+		return dec.assignObjectValue(refVal, val)
 	case valueArray:
-		return assignArrayValue(refVal, val)
+		return dec.assignArrayValue(refVal, val)
 	case valueString:
 		return assignStrValue(refVal, val)
 
@@ -192,128 +548,214 @@ func assignValue(refVal reflect.Value, val *value) error {
 
 	case valueUndefined:
 		return assignUndefinedValue(refVal, val)
+
+	case valueHole:
+		return assignHoleValue(refVal, val)
 	}
 
 	return nil
 }
 
-func assignObjectValue(refVal reflect.Value, val *value) error {
+func (dec *decoder) assignObjectValue(refVal reflect.Value, val *value) error {
 	switch refVal.Kind() {
 	case reflect.Struct:
-		// temporary storage for field
-		// accepted tags are json6, json5, and json
-		storeFields := make(map[string]reflect.Value)
-		storeFieldNames := make(map[string]string)
-		numField := refVal.NumField()
-		for i := 0; i < numField; i++ {
-			field := refVal.Type().Field(i)
-			if tag := field.Tag.Get("json6"); tag != "" {
-				storeFields[tag] = refVal.Field(i)
-				storeFieldNames[tag] = field.Name
-			} else if tag := field.Tag.Get("json5"); tag != "" {
-				storeFields[tag] = refVal.Field(i)
-				storeFieldNames[tag] = field.Name
-			} else if tag := field.Tag.Get("json"); tag != "" {
-				storeFields[tag] = refVal.Field(i)
-				storeFieldNames[tag] = field.Name
-			} else {
-				storeFields[field.Name] = refVal.Field(i)
-				storeFieldNames[field.Name] = field.Name
+		// iterate keys in source order, so when several keys match the same field the last one wins,
+		// like encoding/json
+		for _, k := range val.keys {
+			v := val.objVal[k]
+			f, ok := lookupField(refVal.Type(), k, dec.caseSensitive)
+			if !ok {
+				if dec.disallowUnknownFields {
+					return errUnknownField(k, val.keyPos[k], refVal.Type(), dec.pathString())
+				}
+
+				continue
 			}
-		}
 
-		for k, v := range val.objVal {
-			if rv, ok := storeFields[k]; ok {
-				vc := v
-				err := assignValue(rv, &vc)
-				if err != nil {
-					return fmt.Errorf("error decoding value to %s.%s:\n%s", refVal.Type().Name(), storeFieldNames[k], err.Error())
-				}
+			rv, ok := fieldByIndex(refVal, f.index, true)
+			if !ok {
+				return errSetEmbeddedPtr(k, val.keyPos[k], refVal.Type(), f.goName, dec.pathString())
+			}
+
+			if err := dec.assignField(rv, &v, k, f.asString); err != nil {
+				return err
 			}
 		}
 
 	case reflect.Map:
-		// verify if the map key type is string
+		// map key must be string, integer, or implement encoding.TextUnmarshaler
 		refType := refVal.Type()
-		if refType.Key().Kind() != reflect.String {
-			return fmt.Errorf("can not decode object to map[%s]%s, JSON6 object can only be decoded to struct or map[string]interface{}",
-				refType.Key().String(), refType.Elem().String())
+		keyType := refType.Key()
+		switch keyType.Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+
+		default:
+			if !reflect.PtrTo(keyType).Implements(textUnmarshalerType) {
+				return errMismatchType(val, refType)
+			}
 		}
 
-		// verify if the map elem type is interface{}
-		if refType.Elem().Kind() != reflect.Interface {
-			return fmt.Errorf("can not decode object to map[%s]%s, JSON6 object can only be decoded to struct or map[string]interface{}",
-				refType.Key().String(), refType.Elem().String())
+		if refVal.IsNil() {
+			refVal.Set(reflect.MakeMap(refType))
 		}
 
-		for k, v := range val.objVal {
-			refVal.SetMapIndex(reflect.ValueOf(k), getVal(v))
+		elemType := refType.Elem()
+		// iterate keys in source order, so the error of the first invalid member is returned
+		for _, k := range val.keys {
+			v := val.objVal[k]
+			key, err := mapKey(keyType, k)
+			if err != nil {
+				if typeErr, ok := err.(*UnmarshalTypeError); ok {
+					if val.keyPos[k] != nil {
+						typeErr.Pos = *val.keyPos[k]
+					}
+
+					// path to the member of the invalid key
+					dec.path = append(dec.path, k)
+					dec.setErrPath(typeErr)
+					dec.path = dec.path[:len(dec.path)-1]
+				}
+
+				return err
+			}
+
+			elem := reflect.New(elemType).Elem()
+			vc := v
+			if err := dec.assignElem(elem, &vc, k); err != nil {
+				return err
+			}
+
+			refVal.SetMapIndex(key, elem)
 		}
 
 	case reflect.Interface:
-		refVal.Set(getVal(*val))
+		v, err := dec.getVal(*val)
+		if err != nil {
+			return err
+		}
+
+		refVal.Set(v)
 
 	default:
-		return fmt.Errorf("can not decode object to %s (%s), JSON6 object can only be decoded to struct or map[string]interface{}", refVal.Type().Name(), refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
 }
 
-func assignArrayValue(refVal reflect.Value, val *value) error {
-	switch refVal.Kind() {
-	case reflect.Slice:
-		refValElemKindStr := refVal.Type().Elem().String()
-		refValElemType := refVal.Type().Elem()
-		refVal.SetLen(0)
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 
-		for _, v := range val.arrVal {
-			if v.t == valueNull || v.t == valueUndefined {
-				zeroVal := reflect.Zero(refValElemType)
-				refVal.Set(reflect.Append(refVal, zeroVal))
-				continue
-			}
+// mapKey convert object key into map key of type keyType
+func mapKey(keyType reflect.Type, k string) (reflect.Value, error) {
+	if reflect.PtrTo(keyType).Implements(textUnmarshalerType) {
+		key := reflect.New(keyType)
+		if err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+			return key, err
+		}
+
+		return key.Elem(), nil
+	}
 
-			refV := getVal(v)
+	key := reflect.New(keyType).Elem()
+	switch keyType.Kind() {
+	case reflect.String:
+		key.SetString(k)
 
-			if !refV.Type().ConvertibleTo(refValElemType) {
-				return errMismatchType(v.rnReader.chars, getValTypeStr(v), refValElemKindStr)
-			}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || key.OverflowInt(i) {
+			return key, errMismatchKeyType(k, keyType)
+		}
+
+		key.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(k, 10, 64)
+		if err != nil || key.OverflowUint(u) {
+			return key, errMismatchKeyType(k, keyType)
+		}
+
+		key.SetUint(u)
+	}
+
+	return key, nil
+}
+
+// decodeQuotedValue decode JSON6 value inside string val, used for field with ",string" tag option.
+// The value must be a number, boolean, null, or string
+func (dec *decoder) decodeQuotedValue(val *value, valType reflect.Type) (value, error) {
+	// the quoted value is decoded with the same options as its string
+	quotedDec := &decoder{
+		lx:             NewLexer(strings.NewReader(val.strVal)),
+		loneSurrogates: dec.loneSurrogates,
+		useNumber:      dec.useNumber,
+	}
+
+	token, err := quotedDec.lx.Next()
+	if err != nil {
+		return value{}, errMismatchType(val, valType)
+	}
+
+	switch token.t {
+	case TokenNumber, TokenBool, TokenNull, TokenString:
+	default:
+		return value{}, errMismatchType(val, valType)
+	}
 
-			refVal.Set(reflect.Append(refVal, refV.Convert(refValElemType)))
+	quotedVal, err := quotedDec.decodeTokenValue(token)
+	if err != nil {
+		return value{}, errMismatchType(val, valType)
+	}
+
+	// there must be nothing else in the string
+	if _, err := quotedDec.lx.Next(); err != io.EOF {
+		return value{}, errMismatchType(val, valType)
+	}
+
+	quotedVal.pos = val.pos
+	return quotedVal, nil
+}
+
+func (dec *decoder) assignArrayValue(refVal reflect.Value, val *value) error {
+	switch refVal.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(refVal.Type(), len(val.arrVal), len(val.arrVal))
+		for i := range val.arrVal {
+			v := val.arrVal[i]
+			if err := dec.assignElem(slice.Index(i), &v, i); err != nil {
+				return err
+			}
 		}
 
+		refVal.Set(slice)
+
 	case reflect.Array:
 		refValElemType := refVal.Type().Elem()
 		for i := 0; i < refVal.Len(); i++ {
-			v := val.arrVal[i]
-			if v.t == valueNull || v.t == valueUndefined {
-				zeroVal := reflect.Zero(refValElemType)
-				refVal.Index(i).Set(zeroVal)
+			// zero the rest of elements if JSON6 array is shorter
+			if i >= len(val.arrVal) {
+				refVal.Index(i).Set(reflect.Zero(refValElemType))
 				continue
 			}
 
-			if err := assignValue(refVal.Index(i), &v); err != nil {
+			v := val.arrVal[i]
+			if err := dec.assignElem(refVal.Index(i), &v, i); err != nil {
 				return err
 			}
 		}
 
 	case reflect.Interface:
-		var arr []interface{}
-		for _, v := range val.arrVal {
-			if v.t == valueNull || v.t == valueUndefined {
-				arr = append(arr, nil)
-
-				continue
-			}
-
-			arr = append(arr, getVal(v).Interface())
+		arr, err := dec.getElems(val.arrVal)
+		if err != nil {
+			return err
 		}
 
 		refVal.Set(reflect.ValueOf(arr))
 
 	default:
-		return errMismatchType(val.rnReader.chars, "array", refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
@@ -328,29 +770,40 @@ func assignStrValue(refVal reflect.Value, val *value) error {
 		refVal.Set(reflect.ValueOf(val.strVal))
 
 	default:
-		return errMismatchType(val.rnReader.chars, "string", refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
 }
 
 func assignIntNumValue(refVal reflect.Value, val *value) error {
+	if val.bigVal != nil {
+		return assignBigIntValue(refVal, val)
+	}
+
 	switch refVal.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if refVal.OverflowInt(val.intVal) {
+			return errMismatchType(val, refVal.Type())
+		}
+
 		refVal.SetInt(val.intVal)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if val.intVal < 0 {
-			return errMismatchType(val.rnReader.chars, "integer", refVal.Type().String())
+		if val.intVal < 0 || refVal.OverflowUint(uint64(val.intVal)) {
+			return errMismatchType(val, refVal.Type())
 		}
 
 		refVal.SetUint(uint64(val.intVal))
 
+	case reflect.Float32, reflect.Float64:
+		refVal.SetFloat(float64(val.intVal))
+
 	case reflect.Interface:
 		refVal.Set(reflect.ValueOf(val.intVal))
 
 	default:
-		return errMismatchType(val.rnReader.chars, "integer", refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
@@ -359,13 +812,38 @@ func assignIntNumValue(refVal reflect.Value, val *value) error {
 func assignDoubleNumValue(refVal reflect.Value, val *value) error {
 	switch refVal.Kind() {
 	case reflect.Float32, reflect.Float64:
+		if val.overflow || refVal.OverflowFloat(val.floatVal) {
+			return errMismatchType(val, refVal.Type())
+		}
+
 		refVal.SetFloat(val.floatVal)
 
+	// double without fraction, like 1e3, can be decoded to integer
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := int64(val.floatVal)
+		if float64(i) != val.floatVal || val.floatVal >= math.MaxInt64 || refVal.OverflowInt(i) {
+			return errMismatchType(val, refVal.Type())
+		}
+
+		refVal.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := uint64(val.floatVal)
+		if val.floatVal < 0 || float64(u) != val.floatVal || val.floatVal >= math.MaxUint64 || refVal.OverflowUint(u) {
+			return errMismatchType(val, refVal.Type())
+		}
+
+		refVal.SetUint(u)
+
 	case reflect.Interface:
+		if val.overflow {
+			return errMismatchType(val, float64Type)
+		}
+
 		refVal.Set(reflect.ValueOf(val.floatVal))
 
 	default:
-		return errMismatchType(val.rnReader.chars, "integer", refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
@@ -390,13 +868,18 @@ func assignBoolValue(refVal reflect.Value, val *value) error {
 		refVal.Set(reflect.ValueOf(val.boolVal))
 
 	default:
-		return errMismatchType(val.rnReader.chars, "bool", refVal.Type().String())
+		return errMismatchType(val, refVal.Type())
 	}
 
 	return nil
 }
 
 func assignUndefinedValue(refVal reflect.Value, val *value) error {
+	if refVal.Kind() == reflect.Interface {
+		refVal.Set(reflect.ValueOf(Undefined))
+		return nil
+	}
+
 	if refVal.IsValid() {
 		if !refVal.IsZero() {
 			refVal.Set(reflect.Zero(refVal.Type()))
@@ -406,8 +889,31 @@ func assignUndefinedValue(refVal reflect.Value, val *value) error {
 	return nil
 }
 
-// decodeValue decode any JSON6 value
+// assignHoleValue assign empty array element to refVal, Hole for interface{} and zero value for other types
+func assignHoleValue(refVal reflect.Value, val *value) error {
+	if refVal.Kind() == reflect.Interface {
+		refVal.Set(reflect.ValueOf(Hole))
+		return nil
+	}
+
+	if !refVal.IsZero() {
+		refVal.Set(reflect.Zero(refVal.Type()))
+	}
+
+	return nil
+}
+
+// decodeValue decode any JSON6 value and assign it to dec.refVal
 func (dec *decoder) decodeValue() error {
+	if err := dec.readValue(); err != nil {
+		return err
+	}
+
+	return dec.assignValue(dec.refVal, &dec.val)
+}
+
+// readValue read the only JSON6 value of the input into dec.val
+func (dec *decoder) readValue() error {
 	expect := expectValue
 MAIN_LOOP:
 	for {
@@ -416,7 +922,7 @@ MAIN_LOOP:
 			if err == ErrNoMoreToken {
 				switch expect {
 				case expectValue:
-					return errUnexpectedEndOfTokenStream("any JSON6 value")
+					return errUnexpectedEndOfTokenStream(dec.lx.position(), "any JSON6 value")
 
 				case expectCommentOrEOF:
 					break MAIN_LOOP
@@ -428,112 +934,116 @@ MAIN_LOOP:
 
 		switch expect {
 		case expectValue:
-			switch token.t {
-			case TokenString:
-				dec.val, err = decodeString(token.runeReader)
-				if err != nil {
-					return err
-				}
-
-				expect = expectCommentOrEOF
+			if token.t == TokenComment {
 				continue
+			}
 
-			case TokenNumber:
-				switch token.tokenNumSubType {
-				case tokenNumInteger:
-					dec.val, err = decodeIntNumber(token.runeReader)
-					if err != nil {
-						return err
-					}
-
-				case tokenNumDouble:
-					dec.val, err = decodeIntNumber(token.runeReader)
-					if err != nil {
-						return err
-					}
-				}
+			dec.val, err = dec.decodeTokenValue(token)
+			if err != nil {
+				return err
+			}
 
-				expect = expectCommentOrEOF
-				continue
+			expect = expectCommentOrEOF
 
-			case TokenNull:
-				dec.val = value{t: valueNull}
+		case expectCommentOrEOF:
+			if token.t != TokenComment {
+				return errUnexpectedToken(token, "EOF")
+			}
+		}
+	}
 
-				expect = expectCommentOrEOF
-				continue
+	return nil
+}
 
-			case TokenBool:
-				dec.val = decodeBool(token.runeReader)
+// decodeTokenValue decode JSON6 value started by token,
+// members of object or array are read from the lexer
+func (dec *decoder) decodeTokenValue(token Token) (value, error) {
+	var val value
+	var err error
+	switch token.t {
+	case TokenString:
+		val, err = decodeString(token.runeReader, dec.loneSurrogates)
+
+	case TokenNumber:
+		if token.tokenNumSubType == tokenNumInteger {
+			val, err = decodeIntNumber(token.runeReader)
+		} else {
+			val, err = decodeDoubleNumber(token.runeReader)
+		}
 
-				expect = expectCommentOrEOF
-				continue
+	case TokenNull:
+		val = value{t: valueNull}
 
-			case TokenUndefined:
-				dec.val = value{t: valueUndefined}
+	case TokenBool:
+		val = decodeBool(token.runeReader)
 
-				expect = expectCommentOrEOF
-				continue
+	case TokenUndefined:
+		val = value{t: valueUndefined}
 
-			case TokenPunctuator:
-				char := token.chars[0]
+	case TokenPunctuator:
+		switch token.chars[0] {
+		case '{', '[':
+			if dec.depth >= maxNestingDepth {
+				return val, errMaxDepth(token)
+			}
 
-				if char == '{' {
-					dec.val, err = decodeObject(dec.lx.tokenReader)
-					if err != nil {
-						return err
-					}
-				} else if char == '[' {
-					dec.val, err = decodeArray(dec.lx.tokenReader)
-					if err != nil {
-						return err
-					}
-				} else {
-					return errUnexpectedToken(token, "any JSON6 value")
-				}
+			dec.depth++
+			if token.chars[0] == '{' {
+				val, err = dec.decodeObject()
+			} else {
+				val, err = dec.decodeArray()
+			}
 
-				expect = expectCommentOrEOF
-				continue
+			dec.depth--
+			val.pos = token.StartPos
+			val.start = token.StartPos.Offset()
+			return val, err
+		}
 
-			case TokenComment:
-				continue
-			}
+		return val, errUnexpectedToken(token, "any JSON6 value")
 
-			return errUnexpectedToken(token, "any JSON6 value")
+	default:
+		return val, errUnexpectedToken(token, "any JSON6 value")
+	}
 
-		case expectCommentOrEOF:
-			if token.t != TokenComment {
-				return errUnexpectedToken(token, "EOF")
-			}
-		}
+	if err != nil {
+		return val, errInvalidToken(token, err)
 	}
 
-	return assignValue(dec.refVal, &dec.val)
+	// keep the source characters and position of the value
+	val.rnReader = token.runeReader
+	val.pos = token.StartPos
+	val.start = token.StartPos.Offset()
+	val.end = token.EndOffset()
+
+	return val, nil
 }
 
-func decodeObject(r *tokenReader) (value, error) {
+func (dec *decoder) decodeObject() (value, error) {
 	expect := expectIdentOrPunctCloseCurlBrack
 	var ident string
-	val := value{t: valueObject, objVal: make(map[string]value)}
+	var identPos *Position
+	val := value{t: valueObject, objVal: make(map[string]value), keyPos: make(map[string]*Position)}
 
 	for {
-		token, err := r.ReadToken()
+		token, err := dec.lx.ReadToken()
 		if err != nil {
 			if err == ErrNoMoreToken {
 				switch expect {
 				case expectIdent:
-					return val, errUnexpectedEndOfTokenStream("identifier", "string")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "identifier", "string")
 
 				case expectPunctColon:
-					return val, errUnexpectedEndOfTokenStream("':'")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "':'")
 
 				case expectValue:
-					return val, errUnexpectedEndOfTokenStream("any JSON6 value")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "any JSON6 value")
 
 				case expectPunctComaOrCloseCurlBrack:
-					return val, errUnexpectedEndOfTokenStream("','", "'}'")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "','", "'}'")
 
 				case expectIdentOrPunctCloseCurlBrack:
-					return val, errUnexpectedEndOfTokenStream("identifier", "string", "'}'")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "identifier", "string", "'}'")
 				}
 			}
 
@@ -545,120 +1055,86 @@ func decodeObject(r *tokenReader) (value, error) {
 			continue
 		}
 
-		switch expect {
-		case expectIdent:
-			switch token.t {
-			case TokenIdentifier:
-				ident, err = decodeIdentifier(token.runeReader)
-				if err != nil {
-					return val, err
-				}
-
-				expect = expectPunctColon
-				continue
-
-			case TokenString:
-				val, err := decodeString(token.runeReader)
-				if err != nil {
-					return val, err
-				}
-
-				ident = val.strVal
-				expect = expectPunctColon
-				continue
+		if expect == expectValue {
+			decVal, err := dec.decodeTokenValue(token)
+			if err != nil {
+				return val, err
 			}
 
-			return val, errUnexpectedToken(token, "identifier", "string")
-
-		case expectPunctColon:
-			if token.t == TokenPunctuator {
-				if token.chars[0] == ':' {
-					expect = expectValue
+			if firstPos, ok := val.keyPos[ident]; ok {
+				switch dec.duplicateKeys {
+				case DuplicateKeysReject:
+					return val, errDuplicateKey(ident, firstPos, identPos)
 
+				case DuplicateKeysFirstWins:
+					expect = expectPunctComaOrCloseCurlBrack
 					continue
 				}
 			}
 
-			return val, errUnexpectedToken(token, "':'")
+			if _, ok := val.objVal[ident]; !ok {
+				val.keys = append(val.keys, ident)
+			}
 
-		case expectValue:
-			switch token.t {
-			case TokenNumber:
-				if token.tokenNumSubType == tokenNumInteger {
-					decVal, err := decodeIntNumber(token.runeReader)
-					if err != nil {
-						return val, err
-					}
+			val.objVal[ident] = decVal
+			val.keyPos[ident] = identPos
 
-					val.objVal[ident] = decVal
-				} else {
-					decVal, err := decodeDoubleNumber(token.runeReader)
-					if err != nil {
-						return val, err
-					}
+			expect = expectPunctComaOrCloseCurlBrack
+			continue
+		}
 
-					val.objVal[ident] = decVal
+		switch expect {
+		case expectIdent, expectIdentOrPunctCloseCurlBrack:
+			switch token.t {
+			case TokenIdentifier:
+				ident, err = decodeIdentifier(token.runeReader, dec.loneSurrogates)
+				if err != nil {
+					return val, errInvalidToken(token, err)
 				}
 
-				expect = expectPunctComaOrCloseCurlBrack
+				identPos = token.StartPos
+
+				expect = expectPunctColon
 				continue
 
 			case TokenString:
-				decVal, err := decodeString(token.runeReader)
+				decVal, err := decodeString(token.runeReader, dec.loneSurrogates)
 				if err != nil {
-					return val, err
+					return val, errInvalidToken(token, err)
 				}
 
-				val.objVal[ident] = decVal
-
-				expect = expectPunctComaOrCloseCurlBrack
-				continue
-
-			case TokenNull:
-				val.objVal[ident] = value{t: valueNull}
-
-				expect = expectPunctComaOrCloseCurlBrack
-				continue
-
-			case TokenBool:
-				val.objVal[ident] = decodeBool(token.runeReader)
-
-				expect = expectPunctComaOrCloseCurlBrack
-				continue
-
-			case TokenUndefined:
-				val.objVal[ident] = value{t: valueUndefined}
-
-				expect = expectPunctComaOrCloseCurlBrack
+				ident = decVal.strVal
+				identPos = token.StartPos
+				expect = expectPunctColon
 				continue
 
-			// can be object or array
 			case TokenPunctuator:
-				char := token.chars[0]
-				if char == '{' {
-					decVal, err := decodeObject(r)
-					if err != nil {
-						return val, err
+				if expect == expectIdentOrPunctCloseCurlBrack {
+					if token.chars[0] == '}' {
+						val.end = token.EndOffset()
+						return val, nil
 					}
 
-					val.objVal[ident] = decVal
+					return val, errUnexpectedToken(token, "'}'")
+				}
+			}
 
-					expect = expectPunctComaOrCloseCurlBrack
-					continue
-				} else if char == '[' {
-					decVal, err := decodeArray(r)
-					if err != nil {
-						return val, err
-					}
+			if expect == expectIdent {
+				return val, errUnexpectedToken(token, "identifier", "string")
+			}
 
-					val.objVal[ident] = decVal
+			return val, errUnexpectedToken(token, "identifier", "string", "'}'")
+
+		case expectPunctColon:
+			if token.t == TokenPunctuator {
+				if token.chars[0] == ':' {
+					expect = expectValue
 
-					expect = expectPunctComaOrCloseCurlBrack
 					continue
 				}
 			}
 
-			return val, errUnexpectedToken(token, "any JSON6 value")
+			return val, errUnexpectedToken(token, "':'")
 
 		case expectPunctComaOrCloseCurlBrack:
 			if token.t == TokenPunctuator {
@@ -667,63 +1143,30 @@ func decodeObject(r *tokenReader) (value, error) {
 					expect = expectIdentOrPunctCloseCurlBrack
 					continue
 				} else if char == '}' {
+					val.end = token.EndOffset()
 					return val, nil
 				}
-
-				return val, errUnexpectedToken(token, "','", "'}'")
 			}
 
 			return val, errUnexpectedToken(token, "','", "'}'")
-
-		case expectIdentOrPunctCloseCurlBrack:
-			switch token.t {
-			case TokenIdentifier:
-				ident, err = decodeIdentifier(token.runeReader)
-				if err != nil {
-					return val, err
-				}
-
-				expect = expectPunctColon
-				continue
-
-			case TokenString:
-				decVal, err := decodeString(token.runeReader)
-				if err != nil {
-					return val, err
-				}
-
-				ident = decVal.strVal
-				expect = expectPunctColon
-				continue
-
-			case TokenPunctuator:
-				if token.chars[0] == '}' {
-					return val, nil
-				}
-
-				return val, errUnexpectedToken(token, "'}'")
-			}
-
-			return val, errUnexpectedToken(token, "identifier", "string", "'}'")
 		}
 	}
 }
 
-func decodeArray(r *tokenReader) (value, error) {
+func (dec *decoder) decodeArray() (value, error) {
 	val := value{t: valueArray}
 	expect := expectValueOrPunctComaOrCloseBrack
 
-MAIN_LOOP:
 	for {
-		token, err := r.ReadToken()
+		token, err := dec.lx.ReadToken()
 		if err != nil {
 			if err == ErrNoMoreToken {
 				switch expect {
 				case expectValueOrPunctComaOrCloseBrack:
-					return val, errUnexpectedEndOfTokenStream("any JSON6 value", "','", "']'")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "any JSON6 value", "','", "']'")
 
 				case expectPunctComaOrCloseBrack:
-					return val, errUnexpectedEndOfTokenStream("','", "']'")
+					return val, errUnexpectedEndOfTokenStream(dec.lx.position(), "','", "']'")
 				}
 			}
 
@@ -737,297 +1180,302 @@ MAIN_LOOP:
 
 		switch expect {
 		case expectValueOrPunctComaOrCloseBrack:
-			switch token.t {
-			case TokenString:
-				decVal, err := decodeString(token.runeReader)
-				if err != nil {
-					return val, err
-				}
-
-				val.arrVal = append(val.arrVal, decVal)
-				expect = expectPunctComaOrCloseBrack
-				continue
-
-			case TokenNumber:
-				switch token.tokenNumSubType {
-				case tokenNumInteger:
-					decVal, err := decodeIntNumber(token.runeReader)
-					if err != nil {
-						return val, err
+			if token.t == TokenPunctuator {
+				switch token.chars[0] {
+				case ',':
+					hole := value{t: valueHole, pos: token.StartPos, start: token.StartPos.Offset(), end: token.StartPos.Offset()}
+					switch dec.arrayHoles {
+					case ArrayHolesZero:
+						hole.t = valueNull
+					case ArrayHolesReject:
+						return val, errArrayHole(len(val.arrVal), token.StartPos)
 					}
 
-					val.arrVal = append(val.arrVal, decVal)
-
-				case tokenNumDouble:
-					decVal, err := decodeDoubleNumber(token.runeReader)
-					if err != nil {
-						return val, err
+					if dec.arrayHoles != ArrayHolesSkip {
+						val.arrVal = append(val.arrVal, hole)
 					}
 
-					val.arrVal = append(val.arrVal, decVal)
-				}
-
-				expect = expectPunctComaOrCloseBrack
-				continue
-
-			case TokenNull:
-				val.arrVal = append(val.arrVal, value{t: valueNull})
-
-				expect = expectPunctComaOrCloseBrack
-				continue
-
-			case TokenBool:
-				decVal := decodeBool(token.runeReader)
-				val.arrVal = append(val.arrVal, decVal)
-
-				expect = expectPunctComaOrCloseBrack
-				continue
-
-			case TokenUndefined:
-				val.arrVal = append(val.arrVal, value{t: valueUndefined})
+					continue
 
-				expect = expectPunctComaOrCloseBrack
-				continue
+				case ']':
+					val.end = token.EndOffset()
+					return val, nil
 
-			case TokenPunctuator:
-				char := token.chars[0]
-				switch char {
-				case '{':
-					decVal, err := decodeObject(r)
-					if err != nil {
-						return val, err
-					}
+				case '{', '[':
 
-					val.arrVal = append(val.arrVal, decVal)
-					expect = expectPunctComaOrCloseBrack
-					continue
+				default:
+					return val, errUnexpectedToken(token, "any JSON6 value", "','", "']'")
+				}
+			}
 
-				case '[':
-					decVal, err := decodeArray(r)
-					if err != nil {
-						return val, err
-					}
+			decVal, err := dec.decodeTokenValue(token)
+			if err != nil {
+				return val, err
+			}
 
-					val.arrVal = append(val.arrVal, decVal)
-					expect = expectPunctComaOrCloseBrack
-					continue
+			val.arrVal = append(val.arrVal, decVal)
+			expect = expectPunctComaOrCloseBrack
 
+		case expectPunctComaOrCloseBrack:
+			if token.t == TokenPunctuator {
+				switch token.chars[0] {
 				case ',':
-					val.arrVal = append(val.arrVal, value{t: valueNull})
+					expect = expectValueOrPunctComaOrCloseBrack
 					continue
 
 				case ']':
-					break MAIN_LOOP
+					val.end = token.EndOffset()
+					return val, nil
 				}
-
-				return val, errUnexpectedToken(token, "any JSON6 value", "','", "']'")
-			}
-
-		case expectPunctComaOrCloseBrack:
-			if token.t != TokenPunctuator {
-				return val, errUnexpectedToken(token, "','", "']'")
-			}
-
-			if token.chars[0] == ',' {
-				expect = expectValueOrPunctComaOrCloseBrack
-				continue
-			} else if token.chars[0] == ']' {
-				break MAIN_LOOP
 			}
 
 			return val, errUnexpectedToken(token, "','", "']'")
 		}
 	}
-
-	return val, nil
 }
 
-func decodeString(r *runeReader) (value, error) {
+// decodeString decode string literal, escape sequences are decoded like ECMAScript string literal,
+// and lone surrogate is handled according to surrogates
+func decodeString(r *runeReader, surrogates LoneSurrogatePolicy) (value, error) {
 	var decVal []rune
 	strBegin, _, _ := r.ReadRune()
 
 	for {
-		char, _, _ := r.ReadRune()
+		char, _, err := r.ReadRune()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return value{}, err
+		}
+
 		if char == '\\' {
-			char, _, _ := r.ReadRune()
-			switch char {
-			case '\\':
-				decVal = append(decVal, char)
-				continue
+			decVal, err = decodeEscape(r, decVal)
+			if err != nil {
+				return value{}, err
+			}
 
-			case 'x':
-				decChar := decodeHexaEscape(r)
-				decVal = append(decVal, decChar)
-				continue
+			continue
+		}
 
-			case 'u':
-				decChar := decodeUnicodeEscape(r)
-				decVal = append(decVal, decChar)
-				continue
+		if char == strBegin {
+			break
+		}
 
-			case '\n':
-				continue
+		decVal = append(decVal, char)
+	}
 
-			case '\r':
-				char, _, _ := r.ReadRune()
-				if char == '\n' {
-					continue
-				}
+	decVal, err := combineSurrogates(decVal, surrogates)
+	if err != nil {
+		return value{}, err
+	}
 
-				decVal = append(decVal, char)
-				continue
+	return value{t: valueString, strVal: string(decVal), rnReader: r}, nil
+}
 
-			case '\u2028':
-				continue
+// decodeEscape decode escape sequence after backslash and append it to dst.
+// Line continuation is removed, legacy octal escape like \012 is decoded as octal,
+// and unknown escape like \a or \8 is decoded as the character without backslash
+func decodeEscape(r *runeReader, dst []rune) ([]rune, error) {
+	char, _, _ := r.ReadRune()
+	switch char {
+	case 'x':
+		decChar, err := decodeHexaEscape(r)
+		return append(dst, decChar), err
 
-			case '\u2029':
-				continue
+	case 'u':
+		decChar, err := decodeUnicodeEscape(r)
+		return append(dst, decChar), err
 
-			case 'a':
-				decVal = append(decVal, '\a')
-				continue
+	case '\n', '\u2028', '\u2029':
+		return dst, nil
 
-			case 'b':
-				decVal = append(decVal, '\b')
-				continue
+	case '\r':
+		if char, _, err := r.ReadRune(); err == nil && char != '\n' {
+			r.UnreadRune()
+		}
 
-			case 'f':
-				decVal = append(decVal, '\f')
-				continue
+		return dst, nil
 
-			case 'n':
-				decVal = append(decVal, '\n')
-				continue
+	case 'b':
+		return append(dst, '\b'), nil
 
-			case 'r':
-				decVal = append(decVal, '\r')
-				continue
+	case 'f':
+		return append(dst, '\f'), nil
 
-			case 't':
-				decVal = append(decVal, '\t')
-				continue
+	case 'n':
+		return append(dst, '\n'), nil
 
-			case 'v':
-				decVal = append(decVal, '\v')
-				continue
+	case 'r':
+		return append(dst, '\r'), nil
 
-			case '0':
-				decVal = append(decVal, '\u0000')
-				continue
-			}
+	case 't':
+		return append(dst, '\t'), nil
 
-			decVal = append(decVal, char)
-			continue
+	case 'v':
+		return append(dst, '\v'), nil
+
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		return append(dst, decodeOctalEscape(r, char)), nil
+	}
+
+	return append(dst, char), nil
+}
+
+// decodeOctalEscape decode legacy octal escape sequence started by digit first,
+// up to \377 so the value is at most 0xFF, \0 not followed by octal digit is NUL character
+func decodeOctalEscape(r *runeReader, first rune) rune {
+	maxDigits := 3
+	if first > '3' {
+		maxDigits = 2
+	}
+
+	decChar := first - '0'
+	for i := 1; i < maxDigits; i++ {
+		char, _, err := r.ReadRune()
+		if err != nil {
+			break
 		}
 
-		if char == strBegin {
+		if char < '0' || char > '7' {
+			r.UnreadRune()
 			break
 		}
 
-		decVal = append(decVal, char)
+		decChar = decChar*8 + char - '0'
 	}
 
-	return value{t: valueString, strVal: string(decVal), rnReader: r}, nil
+	return decChar
+}
+
+// combineSurrogates combine escaped UTF-16 surrogate pairs in rns, like \uD83D\uDE00, into one code point.
+// Lone surrogate is replaced by U+FFFD or rejected according to policy
+func combineSurrogates(rns []rune, policy LoneSurrogatePolicy) ([]rune, error) {
+	res := rns[:0]
+	for i := 0; i < len(rns); i++ {
+		char := rns[i]
+		if !utf16.IsSurrogate(char) {
+			res = append(res, char)
+			continue
+		}
+
+		if i+1 < len(rns) {
+			if pair := utf16.DecodeRune(char, rns[i+1]); pair != unicode.ReplacementChar {
+				res = append(res, pair)
+				i++
+				continue
+			}
+		}
+
+		if policy == LoneSurrogatesReject {
+			return nil, errLoneSurrogate(char)
+		}
+
+		res = append(res, unicode.ReplacementChar)
+	}
+
+	return res, nil
 }
 
 func decodeBool(r *runeReader) value {
 	if string(r.chars) == "false" {
-		return value{t: valueBoolean, boolVal: false}
+		return value{t: valueBoolean, boolVal: false, rnReader: r}
 	}
 
 	return value{t: valueBoolean, boolVal: true, rnReader: r}
 }
 
+// splitNumberSign remove sign characters from number and return the rest,
+// isMinus is true if number has odd count of '-'
+func splitNumberSign(chars []rune) (str string, isMinus bool) {
+	i := 0
+	for ; i < len(chars); i++ {
+		if chars[i] == '-' {
+			isMinus = !isMinus
+		} else if chars[i] != '+' {
+			break
+		}
+	}
+
+	return string(chars[i:]), isMinus
+}
+
+// decodeIntNumber decode integer, integer out of int64 range is decoded into value.bigVal
 func decodeIntNumber(r *runeReader) (value, error) {
-	char, _, _ := r.ReadRune()
-	if char == '-' {
-		isMinus := true
-		count := 1
-		for {
-			char, _, _ := r.ReadRune()
-			if char == '-' {
-				count++
-				if isMinus {
-					isMinus = false
-				} else {
-					isMinus = true
-				}
+	str, isMinus := splitNumberSign(r.chars)
 
-				continue
-			}
+	base := 0
+	if len(str) < 2 || str[0] != '0' || !strings.ContainsRune("xXbBoO", rune(str[1])) {
+		// decimal number can have leading zeros, so it must not be parsed as octal
+		base = 10
+		str = strings.ReplaceAll(str, "_", "")
+	}
 
-			break
+	u, err := strconv.ParseUint(str, base, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return decodeBigInt(r, str, base, isMinus)
 		}
 
-		i, err := strconv.ParseInt(string(r.chars[count:]), 0, 64)
-		if err != nil {
-			panic(err.Error())
-		}
+		return value{}, err
+	}
 
-		if isMinus {
-			i = -i
+	var i int64
+	if isMinus {
+		if u > -math.MinInt64 {
+			return decodeBigInt(r, str, base, isMinus)
 		}
 
-		return value{t: valueInteger, intVal: i}, nil
-	}
+		i = int64(-u)
+	} else {
+		if u > math.MaxInt64 {
+			return decodeBigInt(r, str, base, isMinus)
+		}
 
-	i, err := strconv.ParseInt(string(r.chars), 0, 64)
-	if err != nil {
-		panic(err.Error())
+		i = int64(u)
 	}
 
 	return value{t: valueInteger, intVal: i, rnReader: r}, nil
 }
 
-func decodeDoubleNumber(r *runeReader) (value, error) {
-	char, _, _ := r.ReadRune()
-	if char == '-' {
-		isMinus := true
-		count := 1
-		for {
-			char, _, _ := r.ReadRune()
-			if char == '-' {
-				count++
-				if isMinus {
-					isMinus = false
-				} else {
-					isMinus = true
-				}
-
-				continue
-			}
-
-			break
-		}
+// decodeBigInt decode integer str without sign in base, which is out of int64 range
+func decodeBigInt(r *runeReader, str string, base int, isMinus bool) (value, error) {
+	b, ok := new(big.Int).SetString(str, base)
+	if !ok {
+		return value{}, &strconv.NumError{Func: "ParseInt", Num: string(r.chars), Err: strconv.ErrSyntax}
+	}
 
-		i, err := strconv.ParseFloat(string(r.chars[count:]), 64)
-		if err != nil {
-			panic(err.Error())
-		}
+	if isMinus {
+		b.Neg(b)
+	}
 
-		if isMinus {
-			i = -i
-		}
+	return value{t: valueInteger, bigVal: b, rnReader: r}, nil
+}
 
-		return value{t: valueDouble, floatVal: i}, nil
-	} else if char == '+' {
-		i, err := strconv.ParseFloat(string(r.chars[1:]), 64)
-		if err != nil {
-			panic(err.Error())
+// decodeDoubleNumber decode double, double out of float64 range is decoded as infinity with overflow set,
+// whether it is an error depends on the type it is assigned to
+func decodeDoubleNumber(r *runeReader) (value, error) {
+	str, isMinus := splitNumberSign(r.chars)
+	f, err := strconv.ParseFloat(strings.ReplaceAll(str, "_", ""), 64)
+	overflow := false
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+			return value{}, err
 		}
 
-		return value{t: valueDouble, floatVal: i}, nil
+		overflow = true
 	}
 
-	i, err := strconv.ParseFloat(string(r.chars), 0)
-	if err != nil {
-		panic(err.Error())
+	if isMinus {
+		f = -f
 	}
 
-	return value{t: valueDouble, floatVal: i, rnReader: r}, nil
+	return value{t: valueDouble, floatVal: f, overflow: overflow, rnReader: r}, nil
 }
 
-func decodeIdentifier(r *runeReader) (string, error) {
+// decodeIdentifier decode identifier, with unicode and hexadecimal escape sequence
+func decodeIdentifier(r *runeReader, surrogates LoneSurrogatePolicy) (string, error) {
 	var decVal []rune
 
 	for {
@@ -1044,12 +1492,20 @@ func decodeIdentifier(r *runeReader) (string, error) {
 			char, _, _ := r.ReadRune()
 			switch char {
 			case 'x':
-				decChar := decodeHexaEscape(r)
+				decChar, err := decodeHexaEscape(r)
+				if err != nil {
+					return "", err
+				}
+
 				decVal = append(decVal, decChar)
 				continue
 
 			case 'u':
-				decChar := decodeUnicodeEscape(r)
+				decChar, err := decodeUnicodeEscape(r)
+				if err != nil {
+					return "", err
+				}
+
 				decVal = append(decVal, decChar)
 				continue
 			}
@@ -1058,10 +1514,17 @@ func decodeIdentifier(r *runeReader) (string, error) {
 		decVal = append(decVal, char)
 	}
 
+	decVal, err := combineSurrogates(decVal, surrogates)
+	if err != nil {
+		return "", err
+	}
+
 	return string(decVal), nil
 }
 
-func decodeUnicodeEscape(r *runeReader) rune {
+// decodeUnicodeEscape decode unicode escape sequence after "\u", like "00e9" or "{1F600}",
+// surrogate is returned as is so it can be combined with the next escape
+func decodeUnicodeEscape(r *runeReader) (rune, error) {
 	var rns []rune
 	char, _, _ := r.ReadRune()
 	if char != '{' {
@@ -1073,15 +1536,15 @@ func decodeUnicodeEscape(r *runeReader) rune {
 
 		i, err := strconv.ParseInt(string(rns), 16, 32)
 		if err != nil {
-			panic(err.Error())
+			return 0, err
 		}
 
-		return rune(i)
+		return rune(i), nil
 	}
 
 	for {
-		char, _, _ := r.ReadRune()
-		if char == '}' {
+		char, _, err := r.ReadRune()
+		if err != nil || char == '}' {
 			break
 		}
 
@@ -1090,13 +1553,17 @@ func decodeUnicodeEscape(r *runeReader) rune {
 
 	i, err := strconv.ParseInt(string(rns), 16, 32)
 	if err != nil {
-		panic(err.Error())
+		return 0, err
 	}
 
-	return rune(i)
+	if i > unicode.MaxRune {
+		return 0, &strconv.NumError{Func: "ParseInt", Num: string(rns), Err: strconv.ErrRange}
+	}
+
+	return rune(i), nil
 }
 
-func decodeHexaEscape(r *runeReader) rune {
+func decodeHexaEscape(r *runeReader) (rune, error) {
 	var rns []rune
 	for i := 0; i < 2; i++ {
 		char, _, _ := r.ReadRune()
@@ -1105,16 +1572,18 @@ func decodeHexaEscape(r *runeReader) rune {
 
 	i, err := strconv.ParseInt(string(rns), 16, 32)
 	if err != nil {
-		panic(err.Error())
+		return 0, err
 	}
 
-	return rune(i)
+	return rune(i), nil
 }
 
 // indirect walks down v allocating pointers as needed,
 // until it gets to a non-pointer.
+// If it encounters an Unmarshaler, json.Unmarshaler, or encoding.TextUnmarshaler, indirect stops and returns that.
+// If decodingNull is true, indirect stops at the first settable pointer so it can be set to nil.
 // Btw, this piece of code is stolen from encoding/json lol
-func indirect(v reflect.Value, decodingNull bool) reflect.Value {
+func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
 	// Issue #24153 indicates that it is generally not a guaranteed property
 	// that you may round-trip a reflect.Value by calling Value.Addr().Elem()
 	// and expect the value to still be settable for values derived from
@@ -1168,6 +1637,22 @@ func indirect(v reflect.Value, decodingNull bool) reflect.Value {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
 
+		if v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				return u, nil, nil, reflect.Value{}
+			}
+
+			if u, ok := v.Interface().(json.Unmarshaler); ok {
+				return nil, u, nil, reflect.Value{}
+			}
+
+			if !decodingNull {
+				if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+					return nil, nil, u, reflect.Value{}
+				}
+			}
+		}
+
 		if haveAddr {
 			v = v0 // restore original value after round-trip Value.Addr().Elem()
 			haveAddr = false
@@ -1176,9 +1661,10 @@ func indirect(v reflect.Value, decodingNull bool) reflect.Value {
 		}
 	}
 
-	return v
+	return nil, nil, nil, v
 }
 
+// Unmarshal parses the JSON6-encoded data and stores the result in the value pointed to by val
 func Unmarshal(src []byte, val interface{}) error {
 	dec, err := newDecoderFromBytes(src, val)
 	if err != nil {
@@ -1,7 +1,14 @@
 package json6
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -90,7 +97,7 @@ func TestDecodeObject(t *testing.T) {
 		return
 	}
 
-	_, err = decodeObject(dec.lx.tokenReader)
+	_, err = dec.decodeObject()
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -148,7 +155,7 @@ func TestDecodeArray(t *testing.T) {
 		return
 	}
 
-	_, err = decodeArray(dec.lx.tokenReader)
+	_, err = dec.decodeArray()
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -301,6 +308,23 @@ func TestUnmarshalObject(t *testing.T) {
 	fmt.Printf("%#v\n", val)
 }
 
+func TestUnmarshalFoldedKeys(t *testing.T) {
+	var val struct {
+		Timeout int
+		Kind    string `json6:"ks"`
+	}
+
+	// "\u212A" is Kelvin sign, folded to "k"
+	if err := Unmarshal([]byte(`{TIMEOUT: 1, timeOut: 2, "\u212As": 'kelvin'}`), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Timeout != 2 || val.Kind != "kelvin" {
+		t.Errorf("unexpected %#v, expecting timeout 2 and kind kelvin", val)
+	}
+}
+
 func TestUnmarshalObjectMismatchType(t *testing.T) {
 	src :=
 		`
@@ -626,3 +650,721 @@ func TestUnmarshalStrFromd3x0r_JSON6ToMap2(t *testing.T) {
 
 	fmt.Printf("%#v\n", val)
 }
+
+type level int
+
+const (
+	levelDebug level = iota
+	levelInfo
+)
+
+func (l *level) UnmarshalJSON6(byts []byte) error {
+	switch string(byts) {
+	case "'debug'", `"debug"`:
+		*l = levelDebug
+	case "'info'", `"info"`:
+		*l = levelInfo
+	default:
+		return fmt.Errorf("unknown level %s", string(byts))
+	}
+
+	return nil
+}
+
+type rawValue string
+
+func (r *rawValue) UnmarshalJSON6(byts []byte) error {
+	*r = rawValue(byts)
+	return nil
+}
+
+type jsonValue string
+
+func (j *jsonValue) UnmarshalJSON(byts []byte) error {
+	*j = jsonValue(byts)
+	return nil
+}
+
+type textValue string
+
+func (tv *textValue) UnmarshalText(byts []byte) error {
+	*tv = textValue("text:" + string(byts))
+	return nil
+}
+
+func TestUnmarshalUnmarshaler(t *testing.T) {
+	src := `
+	{
+		level: 'info',
+		levelPtr: "debug",
+		raw: {a: 1, /* comment */ b: [1, 'two',, null]},
+		json: {b: 'x', a: [0x10, undefined]},
+		text: 'value',
+		ip: '127.0.0.1',
+	}
+	`
+
+	var val struct {
+		Level    level     `json6:"level"`
+		LevelPtr *level    `json6:"levelPtr"`
+		Raw      rawValue  `json6:"raw"`
+		JSON     jsonValue `json6:"json"`
+		Text     textValue `json6:"text"`
+		IP       net.IP    `json6:"ip"`
+	}
+
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Level != levelInfo {
+		t.Errorf("unexpected level %d, expecting %d", val.Level, levelInfo)
+	}
+
+	if val.LevelPtr == nil || *val.LevelPtr != levelDebug {
+		t.Errorf("unexpected level pointer %v, expecting pointer to %d", val.LevelPtr, levelDebug)
+	}
+
+	if expected := "{a: 1, /* comment */ b: [1, 'two',, null]}"; string(val.Raw) != expected {
+		t.Errorf("unexpected %s, expecting %s", val.Raw, expected)
+	}
+
+	if expected := `{"a":[16,null],"b":"x"}`; string(val.JSON) != expected {
+		t.Errorf("unexpected %s, expecting %s", val.JSON, expected)
+	}
+
+	if expected := "text:value"; string(val.Text) != expected {
+		t.Errorf("unexpected %s, expecting %s", val.Text, expected)
+	}
+
+	if !val.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("unexpected %s, expecting %s", val.IP, "127.0.0.1")
+	}
+}
+
+func TestUnmarshalUnmarshalerError(t *testing.T) {
+	var val level
+	if err := Unmarshal([]byte("'trace'"), &val); err == nil {
+		t.Error("expecting error from UnmarshalJSON6")
+	}
+
+	var text textValue
+	if err := Unmarshal([]byte("123"), &text); err == nil {
+		t.Error("expecting type mismatch error")
+	}
+}
+
+func TestUnmarshalInvalidEscape(t *testing.T) {
+	inputs := []string{
+		`'\u{FFFFFFFFF}'`,
+		`{'\u{}': 1}`,
+	}
+
+	for _, input := range inputs {
+		var val interface{}
+		err := Unmarshal([]byte(input), &val)
+		var synErr *SyntaxError
+		if !errors.As(err, &synErr) {
+			t.Errorf("unexpected %v for %s, expecting *SyntaxError", err, input)
+			continue
+		}
+
+		if synErr.Pos.Line() != 1 {
+			t.Errorf("unexpected line %d for %s, expecting %d", synErr.Pos.Line(), input, 1)
+		}
+	}
+}
+
+func TestUnmarshalStringEscapes(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		// single character escapes
+		{`'\b\f\n\r\t\v'`, "\b\f\n\r\t\v"},
+		{"'\\\\\\'\\\"\\`'", "\\'\"`"},
+		{`'\a\q\8\9'`, "aq89"},
+
+		// line continuations
+		{"'a\\\nb'", "ab"},
+		{"'a\\\r\nb'", "ab"},
+		{"'a\\\rb'", "ab"},
+		{"'a\\\u2028b\\\u2029c'", "abc"},
+
+		// hexadecimal and unicode escapes
+		{`'\x41\x7e'`, "A~"},
+		{`'\u00e9'`, "\u00e9"},
+		{`'\u{41}\u{0000041}'`, "AA"},
+		{`'\u{1F600}'`, "\U0001F600"},
+		{`'\u{10FFFF}'`, "\U0010FFFF"},
+
+		// surrogate pairs
+		{`'\uD83D\uDE00'`, "\U0001F600"},
+		{`'\u{D83D}\u{DE00}'`, "\U0001F600"},
+		{`'\ud83d\u{de00}!'`, "\U0001F600!"},
+
+		// lone surrogates
+		{`'\uD83D'`, "\uFFFD"},
+		{`'a\uD83Db'`, "a\uFFFDb"},
+		{`'\uDE00\uD83D'`, "\uFFFD\uFFFD"},
+		{`'\uD83D\uD83D\uDE00'`, "\uFFFD\U0001F600"},
+
+		// legacy octal escapes
+		{`'\0'`, "\x00"},
+		{`'\08'`, "\x008"},
+		{`'\012'`, "\n"},
+		{`'\101\1010'`, "AA0"},
+		{`'\377'`, "\u00ff"},
+		{`'\400'`, " 0"},
+		{`'\7a'`, "\aa"},
+	}
+
+	for _, test := range tests {
+		var str string
+		if err := Unmarshal([]byte(test.src), &str); err != nil {
+			t.Errorf("unexpected error %s for %s", err.Error(), test.src)
+			continue
+		}
+
+		if str != test.expected {
+			t.Errorf("unexpected %q for %s, expecting %q", str, test.src, test.expected)
+		}
+
+		// object key is decoded the same way
+		var m map[string]int
+		if err := Unmarshal([]byte("{"+test.src+": 1}"), &m); err != nil || m[test.expected] != 1 {
+			t.Errorf("unexpected %v, %v for key %s, expecting key %q", m, err, test.src, test.expected)
+		}
+	}
+
+	invalids := []string{`'\u{}'`, `'\u{110000}'`, `'\u{FFFFFFFFF}'`, `'\u{12'`, `'\u12'`, `'\x4'`, `'\u{1G}'`}
+	for _, src := range invalids {
+		var str string
+		var synErr *SyntaxError
+		if err := Unmarshal([]byte(src), &str); !errors.As(err, &synErr) {
+			t.Errorf("unexpected %v for %s, expecting *SyntaxError", err, src)
+		}
+	}
+}
+
+func TestUnmarshalIntBoundaries(t *testing.T) {
+	inputs := []string{"9223372036854775807", "-9223372036854775808", "0123", "--0x10", "1_000"}
+	expects := []int64{math.MaxInt64, math.MinInt64, 123, 16, 1000}
+	for i, input := range inputs {
+		var val int64
+		if err := Unmarshal([]byte(input), &val); err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if val != expects[i] {
+			t.Errorf("unexpected %d, expecting %d", val, expects[i])
+		}
+	}
+}
+
+func TestUnmarshalBigNumber(t *testing.T) {
+	input := `{
+		hex: 0xDEAD_beef_DEAD_beef_ff,
+		neg: -9223372036854775809,
+		max: 18446744073709551615,
+		exp: 1e30,
+		pi: 3.14159265358979323846264338327950288,
+		inf: -Infinity,
+		any: 0xDEAD_beef_DEAD_beef_ff,
+		lost: -9223372036854775809,
+	}`
+
+	var val struct {
+		Hex  *big.Int
+		Neg  big.Int
+		Max  uint64
+		Exp  *big.Int
+		Pi   *big.Float
+		Inf  big.Float
+		Any  interface{}
+		Lost float64
+	}
+
+	if err := Unmarshal([]byte(input), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	hex, _ := new(big.Int).SetString("deadbeefdeadbeefff", 16)
+	if val.Hex.Cmp(hex) != 0 {
+		t.Errorf("unexpected %s, expecting %s", val.Hex, hex)
+	}
+
+	if val.Neg.String() != "-9223372036854775809" {
+		t.Errorf("unexpected %s, expecting -9223372036854775809", val.Neg.String())
+	}
+
+	if val.Max != math.MaxUint64 {
+		t.Errorf("unexpected %d, expecting %d", val.Max, uint64(math.MaxUint64))
+	}
+
+	if val.Exp.String() != "1000000000000000000000000000000" {
+		t.Errorf("unexpected %s, expecting 1e30", val.Exp)
+	}
+
+	if val.Pi.Text('g', 10) != "3.141592654" {
+		t.Errorf("unexpected %s, expecting 3.141592654", val.Pi.Text('g', 10))
+	}
+
+	if !val.Inf.IsInf() || !val.Inf.Signbit() {
+		t.Errorf("unexpected %s, expecting -Inf", val.Inf.String())
+	}
+
+	if any, ok := val.Any.(*big.Int); !ok || any.Cmp(hex) != 0 {
+		t.Errorf("unexpected %#v, expecting *big.Int %s", val.Any, hex)
+	}
+
+	if val.Lost != -9223372036854775809 {
+		t.Errorf("unexpected %f, expecting %f", val.Lost, -9223372036854775809.0)
+	}
+
+	inputs := []string{"-1", "18446744073709551616", "1.5"}
+	for _, input := range inputs {
+		var u uint64
+		var typeErr *UnmarshalTypeError
+		if err := Unmarshal([]byte(input), &u); !errors.As(err, &typeErr) {
+			t.Errorf("unexpected %v for %s, expecting *UnmarshalTypeError", err, input)
+		}
+	}
+
+	var b big.Int
+	var typeErr *UnmarshalTypeError
+	if err := Unmarshal([]byte("1.5"), &b); !errors.As(err, &typeErr) {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+	}
+}
+
+func TestUnmarshalNumber(t *testing.T) {
+	inputs := []string{"0x1F", "-1_000", "+.5e1_0", "Infinity", "99999999999999999999"}
+	expects := []Number{"31", "-1000", ".5e10", "Infinity", "99999999999999999999"}
+	for i, input := range inputs {
+		var n Number
+		if err := Unmarshal([]byte(input), &n); err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if n != expects[i] {
+			t.Errorf("unexpected %s, expecting %s", n, expects[i])
+		}
+	}
+
+	if i, err := Number("-12").Int64(); err != nil || i != -12 {
+		t.Errorf("unexpected %d, %v, expecting -12", i, err)
+	}
+
+	if f, err := Number(".5e10").Float64(); err != nil || f != .5e10 {
+		t.Errorf("unexpected %f, %v, expecting %f", f, err, .5e10)
+	}
+}
+
+func TestUnmarshalDoubleOutOfRange(t *testing.T) {
+	input := `{big: -1.5e400, num: 1e400, any: [1e400]}`
+	var val struct {
+		Big *big.Float  `json6:"big"`
+		Num Number      `json6:"num"`
+		Any interface{} `json6:"any"`
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Big.IsInf() || val.Big.Text('g', 10) != "-1.5e+400" {
+		t.Errorf("unexpected %s, expecting -1.5e+400", val.Big.Text('g', 10))
+	}
+
+	if val.Num != "1e400" {
+		t.Errorf("unexpected %s, expecting 1e400", val.Num)
+	}
+
+	if !reflect.DeepEqual(val.Any, []interface{}{Number("1e400")}) {
+		t.Errorf("unexpected %#v, expecting %#v", val.Any, []interface{}{Number("1e400")})
+	}
+
+	// without UseNumber, interface{} holds float64
+	var f float64
+	var any interface{}
+	var typeErr *UnmarshalTypeError
+	for _, v := range []interface{}{&f, &any} {
+		if err := Unmarshal([]byte(`1e400`), v); !errors.As(err, &typeErr) {
+			t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+		}
+	}
+
+	err := Unmarshal([]byte(`{list: [1, 1e400]}`), &any)
+	if !errors.As(err, &typeErr) || typeErr.Path != "$.list[1]" {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $.list[1]", err)
+	}
+
+	var f32 float32
+	if err := Unmarshal([]byte(`1e300`), &f32); !errors.As(err, &typeErr) {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+	}
+}
+
+func TestUnmarshalMapErrorOrder(t *testing.T) {
+	// map iteration order is random, run several times to catch it
+	for i := 0; i < 20; i++ {
+		var m map[string]int
+		var typeErr *UnmarshalTypeError
+		err := Unmarshal([]byte(`{z: 'x', a: 'y', m: 'z'}`), &m)
+		if !errors.As(err, &typeErr) || typeErr.Path != "$.z" {
+			t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $.z", err)
+			return
+		}
+
+		var any interface{}
+		err = Unmarshal([]byte(`{z: 1e400, a: 1e400, m: 1e400}`), &any)
+		if !errors.As(err, &typeErr) || typeErr.Path != "$.z" {
+			t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $.z", err)
+			return
+		}
+	}
+}
+
+func TestUnmarshalField(t *testing.T) {
+	src := `{timeout: 30, retries: null, proxy: undefined, list: [null, undefined]}`
+	var val struct {
+		Timeout Field `json6:"timeout"`
+		Retries Field `json6:"retries"`
+		Proxy   Field `json6:"proxy"`
+		Host    Field `json6:"host"`
+		List    []interface{}
+	}
+
+	val.Timeout.Value = new(int)
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	states := []FieldState{val.Timeout.State, val.Retries.State, val.Proxy.State, val.Host.State}
+	expects := []FieldState{FieldSet, FieldNull, FieldUndefined, FieldMissing}
+	if !reflect.DeepEqual(states, expects) {
+		t.Errorf("unexpected %v, expecting %v", states, expects)
+	}
+
+	if timeout, ok := val.Timeout.Value.(*int); !ok || *timeout != 30 {
+		t.Errorf("unexpected %#v, expecting pointer to 30", val.Timeout.Value)
+	}
+
+	if !reflect.DeepEqual(val.List, []interface{}{nil, Undefined}) {
+		t.Errorf("unexpected %#v, expecting %#v", val.List, []interface{}{nil, Undefined})
+	}
+
+	var typeErr *UnmarshalTypeError
+	err := Unmarshal([]byte(`{timeout: 'x'}`), &val)
+	if !errors.As(err, &typeErr) || typeErr.Path != "$.timeout" {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $.timeout", err)
+	}
+}
+
+func TestUnmarshalDeepNesting(t *testing.T) {
+	src := strings.Repeat("[", maxNestingDepth+1) + strings.Repeat("]", maxNestingDepth+1)
+	var val interface{}
+	if err := Unmarshal([]byte(src), &val); err == nil {
+		t.Error("expecting max nesting depth error")
+	}
+}
+
+// allocation of nested arrays must grow linearly with the input, not with input size times depth
+func TestUnmarshalNestingAllocations(t *testing.T) {
+	alloc := func(depth int) uint64 {
+		src := []byte(strings.Repeat("[", depth) + strings.Repeat("]", depth))
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		before := stats.TotalAlloc
+
+		var val interface{}
+		if err := Unmarshal(src, &val); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		runtime.ReadMemStats(&stats)
+		return stats.TotalAlloc - before
+	}
+
+	small, large := alloc(1000), alloc(8000)
+	if ratio := float64(large) / float64(small); ratio > 16 {
+		t.Errorf("allocated %d bytes for depth 8000 and %d bytes for depth 1000, expecting linear growth", large, small)
+	}
+}
+
+type service struct {
+	Host string `json6:"host"`
+	Port int    `json6:"port"`
+}
+
+type upperKey string
+
+func (k *upperKey) UnmarshalText(byts []byte) error {
+	*k = upperKey(strings.ToUpper(string(byts)))
+	return nil
+}
+
+func TestUnmarshalObjectToTypedMap(t *testing.T) {
+	src := `
+	{
+		services: {
+			api: {host: 'localhost', port: 8080},
+			db: {host: 'db.local', port: 5432},
+		},
+		groups: {admin: ['root', 'franklin'], guest: []},
+		rules: {'1': 'allow', "20": 'deny'},
+		upper: {key: 1},
+		ptrs: {a: 1, b: null},
+	}
+	`
+
+	var val struct {
+		Services map[string]service  `json6:"services"`
+		Groups   map[string][]string `json6:"groups"`
+		Rules    map[int]string      `json6:"rules"`
+		Upper    map[upperKey]uint8  `json6:"upper"`
+		Ptrs     map[string]*int     `json6:"ptrs"`
+	}
+
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if svc := val.Services["db"]; svc.Host != "db.local" || svc.Port != 5432 {
+		t.Errorf("unexpected %#v, expecting host %s and port %d", svc, "db.local", 5432)
+	}
+
+	if admins := val.Groups["admin"]; len(admins) != 2 || admins[1] != "franklin" {
+		t.Errorf("unexpected %#v, expecting [root franklin]", admins)
+	}
+
+	if val.Rules[20] != "deny" {
+		t.Errorf("unexpected %s, expecting %s", val.Rules[20], "deny")
+	}
+
+	if val.Upper["KEY"] != 1 {
+		t.Errorf("unexpected %d, expecting %d", val.Upper["KEY"], 1)
+	}
+
+	if p, ok := val.Ptrs["b"]; !ok || p != nil || *val.Ptrs["a"] != 1 {
+		t.Errorf("unexpected %#v, expecting a pointing to 1 and nil b", val.Ptrs)
+	}
+}
+
+func TestUnmarshalObjectToMapInvalidKey(t *testing.T) {
+	var val map[uint8]string
+	err := Unmarshal([]byte("{'256': 'overflow'}"), &val)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Path != "$['256']" {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $['256']", err)
+	}
+
+	var nested struct {
+		Rules map[int]string `json6:"rules"`
+	}
+
+	err = Unmarshal([]byte("{rules: {'1': 'allow', x: 'deny'}}"), &nested)
+	if !errors.As(err, &typeErr) || typeErr.Path != "$.rules.x" || typeErr.Pos.Column() != 24 {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError at $.rules.x at 1:24", err)
+	}
+}
+
+func TestUnmarshalArrayToTypedSlice(t *testing.T) {
+	src := `
+	{
+		services: [
+			{host: 'localhost', port: 8080},
+			null,
+			{host: 'db.local', port: 5432},
+		],
+		matrix: [[1, 2], [], [3]],
+		ptrs: [{host: 'a'}, null],
+		maps: [{a: 1}, {b: 2}],
+	}
+	`
+
+	var val struct {
+		Services []service         `json6:"services"`
+		Matrix   [][]int           `json6:"matrix"`
+		Ptrs     []*service        `json6:"ptrs"`
+		Maps     []map[string]int8 `json6:"maps"`
+	}
+
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	expectedServices := []service{{Host: "localhost", Port: 8080}, {}, {Host: "db.local", Port: 5432}}
+	if !reflect.DeepEqual(val.Services, expectedServices) {
+		t.Errorf("unexpected %#v, expecting %#v", val.Services, expectedServices)
+	}
+
+	expectedMatrix := [][]int{{1, 2}, {}, {3}}
+	if !reflect.DeepEqual(val.Matrix, expectedMatrix) {
+		t.Errorf("unexpected %#v, expecting %#v", val.Matrix, expectedMatrix)
+	}
+
+	if len(val.Ptrs) != 2 || val.Ptrs[0].Host != "a" || val.Ptrs[1] != nil {
+		t.Errorf("unexpected %#v, expecting pointer to service and nil", val.Ptrs)
+	}
+
+	if len(val.Maps) != 2 || val.Maps[1]["b"] != 2 {
+		t.Errorf("unexpected %#v, expecting [{a: 1}, {b: 2}]", val.Maps)
+	}
+}
+
+func TestUnmarshalArrayToSliceMismatchType(t *testing.T) {
+	var val []string
+	err := Unmarshal([]byte("['a', 65]"), &val)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+	}
+}
+
+type tagBase struct {
+	ID int `json6:"id"`
+}
+
+type tagOptionsVal struct {
+	Base    tagBase `json6:",inline"`
+	Name    string  `json6:"name,omitempty"`
+	Secret  string  `json6:"-"`
+	Count   int     `json6:"count,string"`
+	Enabled *bool   `json6:"enabled,string"`
+}
+
+func TestUnmarshalTagOptions(t *testing.T) {
+	src := `{id: 7, name: 'a', Secret: 'x', '-': 'y', count: '0x10', enabled: "true"}`
+	var val tagOptionsVal
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Base.ID != 7 || val.Name != "a" || val.Secret != "" || val.Count != 16 || val.Enabled == nil || !*val.Enabled {
+		t.Errorf("unexpected %#v", val)
+	}
+
+	if err := Unmarshal([]byte(`{count: '12 34'}`), &val); err == nil {
+		t.Error("expecting error for invalid quoted value")
+	}
+}
+
+type BaseConfig struct {
+	Name    string `json6:"name"`
+	Version int    `json6:"version"`
+}
+
+type ExtraConfig struct {
+	Release string `json6:"Release"`
+	Debug   bool   `json6:"debug"`
+}
+
+type AuditConfig struct {
+	Release int  // hidden by ExtraConfig.Release, which is tagged
+	Debug   bool `json6:"debug"` // conflicts with ExtraConfig.Debug
+}
+
+type appConfig struct {
+	BaseConfig
+	*ExtraConfig
+	AuditConfig
+	Name string `json6:"name"` // shadows BaseConfig.Name
+}
+
+func TestUnmarshalEmbeddedStruct(t *testing.T) {
+	src := `{name: 'app', version: 2, debug: true, Release: 'x'}`
+	var val appConfig
+	if err := Unmarshal([]byte(src), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Name != "app" || val.BaseConfig.Name != "" {
+		t.Errorf("unexpected name %q and base name %q", val.Name, val.BaseConfig.Name)
+	}
+
+	if val.BaseConfig.Version != 2 {
+		t.Errorf("unexpected version %d, expecting 2", val.BaseConfig.Version)
+	}
+
+	if val.ExtraConfig == nil || val.ExtraConfig.Release != "x" || val.AuditConfig.Release != 0 {
+		t.Errorf("unexpected release: %#v", val)
+	}
+
+	// debug is ambiguous, so neither field is set
+	if val.AuditConfig.Debug || (val.ExtraConfig != nil && val.ExtraConfig.Debug) {
+		t.Errorf("unexpected debug is set: %#v", val)
+	}
+
+	fieldNames := make([]string, 0)
+	for _, f := range structFields(reflect.TypeOf(val)) {
+		fieldNames = append(fieldNames, f.name)
+	}
+
+	if !reflect.DeepEqual(fieldNames, []string{"version", "Release", "name"}) {
+		t.Errorf("unexpected fields %v", fieldNames)
+	}
+}
+
+type embeddedPtrConfig struct {
+	*BaseConfig
+	Port int `json6:"port"`
+}
+
+func TestUnmarshalEmbeddedPtrStruct(t *testing.T) {
+	var val embeddedPtrConfig
+	if err := Unmarshal([]byte(`{name: 'app', port: 80}`), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.BaseConfig == nil || val.BaseConfig.Name != "app" || val.Port != 80 {
+		t.Errorf("unexpected %#v", val)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	src := `// comment
+	{
+		zeta: 0x1F, octal: 0o17, binary: 0b101, big: 1_000_000, huge: -0xFFFF_FFFF_FFFF_FFFF_F,
+		alpha: [.5, +1, , undefined, 'it\'s', ` + "`tick`" + `],
+		/* nested */ nested: {n: null, t: true},
+	}`
+
+	expected := `{"zeta":31,"octal":15,"binary":5,"big":1000000,"huge":-295147905179352825855,"alpha":[0.5,1,null,null,"it's","tick"],"nested":{"n":null,"t":true}}`
+	byts, err := ToJSON([]byte(src))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+
+	// HTML characters are not escaped
+	src = `{'<a&b>': "<script>"}`
+	expected = `{"<a&b>":"<script>"}`
+	if byts, err := ToJSON([]byte(src)); err != nil || string(byts) != expected {
+		t.Errorf("unexpected %s, %v, expecting %s", string(byts), err, expected)
+	}
+
+	for _, src := range []string{"[NaN]", "{a: -Infinity}", "{a: }"} {
+		if _, err := ToJSON([]byte(src)); err == nil {
+			t.Errorf("expecting error for %s", src)
+		}
+	}
+}
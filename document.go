@@ -0,0 +1,434 @@
+package json6
+
+import (
+	"bytes"
+	"io"
+)
+
+// Comment is a comment in a Document
+type Comment struct {
+	Text string // source text of the comment, including "//" or "/*" and "*/"
+	Pos  Position
+	end  int // byte offset right after the last character of the comment
+}
+
+// Document is a JSON6 document that keeps comments, blank lines, and formatting of its source.
+// Values can be replaced with DocValue.Set or DocValue.SetRaw, and Bytes write the document back
+// with everything else untouched, so a program can edit a hand written file without reformatting it
+type Document struct {
+	Root     *DocValue
+	Leading  []Comment // comments before the root value
+	Trailing []Comment // comments after the root value
+
+	src []byte
+
+	// byte range of the root value in src, kept when the root value is replaced
+	rootStart int
+	rootEnd   int
+}
+
+// DocEntry is a member of object or an element of array in a Document, with comments attached to it
+type DocEntry struct {
+	Key             string    // object key, empty for array element
+	KeyPos          Position  // position of the key, zero for array element
+	Leading         []Comment // comments on lines before the entry
+	Trailing        []Comment // comments after the entry, on the same line
+	BlankLineBefore bool      // the entry is separated from the previous entry or opening bracket by blank line
+	Value           *DocValue
+
+	// byte range of the value in source of the parent, kept when the value is replaced
+	start int
+	end   int
+}
+
+// DocValue is a value in a Document
+type DocValue struct {
+	Kind    Kind
+	Pos     Position    // position of the value in source, or in the text given to SetRaw
+	Entries []*DocEntry // members of object or elements of array
+	Inner   []Comment   // comments after the last entry, before the closing bracket
+
+	src   []byte // source that start and end refer to
+	start int    // byte offset of the first character of the value
+	end   int    // byte offset right after the last character of the value
+}
+
+// ParseDocument parse src into Document
+func ParseDocument(src []byte) (*Document, error) {
+	// validate the document first, so the parser can focus on the structure
+	if err := validate(src); err != nil {
+		return nil, err
+	}
+
+	p := &docParser{lx: NewLexer(bytes.NewReader(src)), src: src}
+	token, comments, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{src: src, Leading: comments}
+	doc.Root, err = p.parseValue(token)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.rootStart, doc.rootEnd = doc.Root.start, doc.Root.end
+
+	token, doc.Trailing, err = p.next()
+	if err == nil {
+		return nil, errUnexpectedToken(token, "EOF")
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// validate check if src is a valid JSON6 text
+func validate(src []byte) error {
+	var node Node
+	return Unmarshal(src, &node)
+}
+
+// Bytes return source of the document with edits applied
+func (doc *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(doc.src[:doc.rootStart])
+	doc.Root.write(&buf)
+	buf.Write(doc.src[doc.rootEnd:])
+
+	return buf.Bytes()
+}
+
+// Decode decode the document with edits applied into v, like Unmarshal
+func (doc *Document) Decode(v interface{}) error {
+	return Unmarshal(doc.Bytes(), v)
+}
+
+// Get get value of object member by key, nil if the value is not an object or the key is not found
+func (v *DocValue) Get(key string) *DocValue {
+	if v.Kind != KindObject {
+		return nil
+	}
+
+	for _, entry := range v.Entries {
+		if entry.Key == key {
+			return entry.Value
+		}
+	}
+
+	return nil
+}
+
+// Index get array element at index i, nil if the value is not an array or i is out of range
+func (v *DocValue) Index(i int) *DocValue {
+	if v.Kind != KindArray || i < 0 || i >= len(v.Entries) {
+		return nil
+	}
+
+	return v.Entries[i].Value
+}
+
+// Raw return source text of the value with edits applied
+func (v *DocValue) Raw() string {
+	var buf bytes.Buffer
+	v.write(&buf)
+
+	return buf.String()
+}
+
+// SetRaw replace the value with JSON6 text raw, raw must be a single valid JSON6 value.
+// Entries of the value are replaced by entries parsed from raw
+func (v *DocValue) SetRaw(raw string) error {
+	src := []byte(raw)
+	if err := validate(src); err != nil {
+		return err
+	}
+
+	p := &docParser{lx: NewLexer(bytes.NewReader(src)), src: src}
+	token, _, err := p.next()
+	if err != nil {
+		return err
+	}
+
+	newVal, err := p.parseValue(token)
+	if err != nil {
+		return err
+	}
+
+	*v = *newVal
+
+	return nil
+}
+
+// Set replace the value with JSON6 encoding of x, see Marshal.
+// Replacing a string keeps its quote character
+func (v *DocValue) Set(x interface{}) error {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if v.Kind == KindString && v.end > v.start {
+		enc.SetQuote(rune(v.src[v.start]))
+	}
+
+	if err := enc.Encode(x); err != nil {
+		return err
+	}
+
+	return v.SetRaw(string(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})))
+}
+
+// write write source of the value to buf, with source of entry values replaced by their current source
+func (v *DocValue) write(buf *bytes.Buffer) {
+	cursor := v.start
+	for _, entry := range v.Entries {
+		buf.Write(v.src[cursor:entry.start])
+		entry.Value.write(buf)
+		cursor = entry.end
+	}
+
+	buf.Write(v.src[cursor:v.end])
+}
+
+// docParser parse tokens into Document, the source must be validated first
+type docParser struct {
+	lx  *Lexer
+	src []byte
+}
+
+// next return the next token that is not comment, and comments before it
+func (p *docParser) next() (Token, []Comment, error) {
+	var comments []Comment
+	for {
+		token, err := p.lx.Next()
+		if err != nil {
+			return token, comments, err
+		}
+
+		if token.t != TokenComment {
+			return token, comments, nil
+		}
+
+		comments = append(comments, Comment{Text: string(token.chars), Pos: *token.StartPos, end: token.end})
+	}
+}
+
+// nextPunct return the next token that is not comment, it must be one of puncts
+func (p *docParser) nextPunct(puncts ...rune) (Token, []Comment, error) {
+	token, comments, err := p.next()
+	if err != nil {
+		if err == io.EOF {
+			return token, comments, errUnexpectedEndOfTokenStream(p.lx.position(), quotePuncts(puncts)...)
+		}
+
+		return token, comments, err
+	}
+
+	if token.t == TokenPunctuator {
+		for _, punct := range puncts {
+			if token.chars[0] == punct {
+				return token, comments, nil
+			}
+		}
+	}
+
+	return token, comments, errUnexpectedToken(token, quotePuncts(puncts)...)
+}
+
+func quotePuncts(puncts []rune) []string {
+	var strs []string
+	for _, punct := range puncts {
+		strs = append(strs, "'"+string(punct)+"'")
+	}
+
+	return strs
+}
+
+func (p *docParser) parseValue(token Token) (*DocValue, error) {
+	v := &DocValue{Pos: *token.StartPos, src: p.src, start: token.StartPos.Offset(), end: token.end}
+	if token.t == TokenPunctuator {
+		switch token.chars[0] {
+		case '{':
+			return v, p.parseObject(v)
+
+		case '[':
+			return v, p.parseArray(v)
+		}
+	}
+
+	val, err := (&decoder{}).decodeTokenValue(token)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Kind = valueKinds[val.t]
+
+	return v, nil
+}
+
+func (p *docParser) parseObject(v *DocValue) error {
+	v.Kind = KindObject
+	var last *DocEntry
+	lastEnd := v.end
+	for {
+		token, comments, err := p.next()
+		if err != nil {
+			if err == io.EOF {
+				return errUnexpectedEndOfTokenStream(p.lx.position(), "identifier", "string", "'}'")
+			}
+
+			return err
+		}
+
+		leading, gapStart := p.attachTrailing(last, lastEnd, comments)
+		if token.t == TokenPunctuator && token.chars[0] == '}' {
+			v.Inner = leading
+			v.end = token.end
+			return nil
+		}
+
+		var key string
+		switch token.t {
+		case TokenIdentifier:
+			key, err = decodeIdentifier(token.runeReader, LoneSurrogatesReplace)
+
+		case TokenString:
+			var keyVal value
+			keyVal, err = decodeString(token.runeReader, LoneSurrogatesReplace)
+			key = keyVal.strVal
+
+		default:
+			return errUnexpectedToken(token, "identifier", "string", "'}'")
+		}
+
+		if err != nil {
+			return errInvalidToken(token, err)
+		}
+
+		entry := &DocEntry{Key: key, KeyPos: *token.StartPos, Leading: leading}
+		entry.BlankLineBefore = p.hasBlankLine(gapStart, firstOffset(leading, token))
+		if _, _, err := p.nextPunct(':'); err != nil {
+			return err
+		}
+
+		token, _, err = p.next()
+		if err != nil {
+			if err == io.EOF {
+				return errUnexpectedEndOfTokenStream(p.lx.position(), "any JSON6 value")
+			}
+
+			return err
+		}
+
+		if entry.Value, err = p.parseValue(token); err != nil {
+			return err
+		}
+
+		entry.start, entry.end = entry.Value.start, entry.Value.end
+		v.Entries = append(v.Entries, entry)
+		token, comments, err = p.nextPunct(',', '}')
+		if err != nil {
+			return err
+		}
+
+		// comments between the value and comma or closing bracket
+		entry.Trailing = comments
+		if token.chars[0] == '}' {
+			v.end = token.end
+			return nil
+		}
+
+		last = entry
+		lastEnd = token.end
+	}
+}
+
+func (p *docParser) parseArray(v *DocValue) error {
+	v.Kind = KindArray
+	var last *DocEntry
+	lastEnd := v.end
+	for {
+		token, comments, err := p.next()
+		if err != nil {
+			if err == io.EOF {
+				return errUnexpectedEndOfTokenStream(p.lx.position(), "any JSON6 value", "','", "']'")
+			}
+
+			return err
+		}
+
+		leading, gapStart := p.attachTrailing(last, lastEnd, comments)
+		entry := &DocEntry{Leading: leading}
+		entry.BlankLineBefore = p.hasBlankLine(gapStart, firstOffset(leading, token))
+		if token.t == TokenPunctuator {
+			switch token.chars[0] {
+			case ']':
+				v.Inner = leading
+				v.end = token.end
+				return nil
+
+			case ',':
+				// empty element, the value is inserted before the comma if replaced
+				start := token.StartPos.Offset()
+				entry.Value = &DocValue{Kind: KindHole, Pos: *token.StartPos, src: p.src, start: start, end: start}
+				entry.start, entry.end = entry.Value.start, entry.Value.end
+				v.Entries = append(v.Entries, entry)
+				last = entry
+				lastEnd = token.end
+				continue
+			}
+		}
+
+		if entry.Value, err = p.parseValue(token); err != nil {
+			return err
+		}
+
+		entry.start, entry.end = entry.Value.start, entry.Value.end
+		v.Entries = append(v.Entries, entry)
+		token, comments, err = p.nextPunct(',', ']')
+		if err != nil {
+			return err
+		}
+
+		entry.Trailing = comments
+		if token.chars[0] == ']' {
+			v.end = token.end
+			return nil
+		}
+
+		last = entry
+		lastEnd = token.end
+	}
+}
+
+// attachTrailing append comments on the same line as lastEnd to trailing comments of last entry,
+// the rest of comments are returned as leading comments of the next entry,
+// gapStart is the offset after the last trailing comment, or lastEnd
+func (p *docParser) attachTrailing(last *DocEntry, lastEnd int, comments []Comment) (leading []Comment, gapStart int) {
+	gapStart = lastEnd
+	for i, comment := range comments {
+		if last == nil || countLines(p.src[gapStart:comment.Pos.Offset()]) > 0 {
+			return comments[i:], gapStart
+		}
+
+		last.Trailing = append(last.Trailing, comment)
+		gapStart = comment.end
+	}
+
+	return nil, gapStart
+}
+
+// hasBlankLine check if there's a blank line between start and end offset
+func (p *docParser) hasBlankLine(start, end int) bool {
+	return countLines(p.src[start:end]) >= 2
+}
+
+// firstOffset return offset of the first leading comment, or offset of token if there's no leading comment
+func firstOffset(leading []Comment, token Token) int {
+	if len(leading) > 0 {
+		return leading[0].Pos.Offset()
+	}
+
+	return token.StartPos.Offset()
+}
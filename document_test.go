@@ -0,0 +1,188 @@
+package json6
+
+import (
+	"strings"
+	"testing"
+)
+
+const documentSrc = `// package manifest
+{
+	name: 'app', // the name
+	version: '1.2',
+
+	/* dependencies,
+	   sorted by name */
+	deps: [
+		'a', // first
+		'b',
+	],
+	build: {count: 10}, // not used
+	// end of members
+}
+// trailing
+`
+
+func TestParseDocument(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSrc))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(doc.Bytes()) != documentSrc {
+		t.Errorf("unexpected %s, expecting unchanged source", string(doc.Bytes()))
+	}
+
+	if len(doc.Leading) != 1 || doc.Leading[0].Text != "// package manifest" {
+		t.Errorf("unexpected leading comments %#v", doc.Leading)
+	}
+
+	if len(doc.Trailing) != 1 || doc.Trailing[0].Text != "// trailing" {
+		t.Errorf("unexpected trailing comments %#v", doc.Trailing)
+	}
+
+	root := doc.Root
+	if root.Kind != KindObject || len(root.Entries) != 4 {
+		t.Errorf("unexpected kind %s with %d entries", root.Kind, len(root.Entries))
+		return
+	}
+
+	name := root.Entries[0]
+	if len(name.Trailing) != 1 || name.Trailing[0].Text != "// the name" || len(name.Leading) != 0 {
+		t.Errorf("unexpected comments of name %#v", name)
+	}
+
+	deps := root.Entries[2]
+	if !deps.BlankLineBefore || len(deps.Leading) != 1 || deps.Leading[0].Pos.Line() != 6 {
+		t.Errorf("unexpected deps %#v", deps)
+	}
+
+	if len(deps.Value.Entries) != 2 || len(deps.Value.Entries[0].Trailing) != 1 {
+		t.Errorf("unexpected deps value %#v", deps.Value)
+	}
+
+	if root.Entries[1].BlankLineBefore {
+		t.Error("unexpected blank line before version")
+	}
+
+	if len(root.Inner) != 1 || root.Inner[0].Text != "// end of members" {
+		t.Errorf("unexpected inner comments %#v", root.Inner)
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSrc))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Get("version").Set("1.3"); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Get("deps").Index(1).SetRaw("{name: 'b', optional: true}"); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Get("deps").Index(1).Get("optional").Set(false); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Get("build").SetRaw("{ count: ]"); err == nil {
+		t.Error("expecting error for invalid value")
+	}
+
+	expected := `// package manifest
+{
+	name: 'app', // the name
+	version: '1.3',
+
+	/* dependencies,
+	   sorted by name */
+	deps: [
+		'a', // first
+		{name: 'b', optional: false},
+	],
+	build: {count: 10}, // not used
+	// end of members
+}
+// trailing
+`
+
+	if string(doc.Bytes()) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(doc.Bytes()), expected)
+	}
+
+	var val struct {
+		Version string `json6:"version"`
+	}
+
+	if err := doc.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Version != "1.3" {
+		t.Errorf("unexpected version %s, expecting 1.3", val.Version)
+	}
+}
+
+func TestDocumentSetUndefined(t *testing.T) {
+	src := "{\n  a: undefined\n  // about b\n  , b: 1\n}"
+	doc, err := ParseDocument([]byte(src))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Get("a").Set(5); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	expected := "{\n  a: 5\n  // about b\n  , b: 1\n}"
+	if string(doc.Bytes()) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(doc.Bytes()), expected)
+	}
+}
+
+func TestParseDocumentEmptyElement(t *testing.T) {
+	doc, err := ParseDocument([]byte(`[1,,3]`))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := doc.Root.Index(1).Set(2); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(doc.Bytes()) != `[1,2,3]` {
+		t.Errorf("unexpected %s, expecting %s", string(doc.Bytes()), `[1,2,3]`)
+	}
+}
+
+func TestParseDocumentLineTerminators(t *testing.T) {
+	for _, ln := range []string{"\r", "\r\n", "\u2028", "\u2029"} {
+		src := strings.Join([]string{"{", "\ta: 1, /* a */", "\t/* b */", "\tb: 2,", "", "\tc: 3,", "}"}, ln)
+		doc, err := ParseDocument([]byte(src))
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		a, b, c := doc.Root.Entries[0], doc.Root.Entries[1], doc.Root.Entries[2]
+		if len(a.Trailing) != 1 || len(b.Leading) != 1 || b.Leading[0].Text != "/* b */" {
+			t.Errorf("unexpected comments %#v and %#v with %q lines", a, b, ln)
+		}
+
+		if b.BlankLineBefore || !c.BlankLineBefore {
+			t.Errorf("unexpected blank lines before b %t and c %t with %q lines", b.BlankLineBefore, c.BlankLineBefore, ln)
+		}
+	}
+}
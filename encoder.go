@@ -0,0 +1,804 @@
+package json6
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Marshaler is the interface implemented by types that can marshal themselves into valid JSON6.
+// The output is re-encoded in the style of the encoder, so comments are dropped
+type Marshaler interface {
+	MarshalJSON6() ([]byte, error)
+}
+
+// Encoder writes JSON6 values to an output stream
+type Encoder struct {
+	w             io.Writer
+	prefix        string
+	indent        string
+	trailingComma bool
+	quoteKeys     bool
+	quote         rune
+}
+
+// NewEncoder initiate new Encoder that writes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, quote: '"'}
+}
+
+// SetIndent instructs the encoder to format each subsequent encoded value
+// as if indented by MarshalIndent
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix = prefix
+	enc.indent = indent
+}
+
+// TrailingComma determine if encoder will write a comma after
+// the last member of non-empty objects and arrays
+func (enc *Encoder) TrailingComma(trailing bool) {
+	enc.trailingComma = trailing
+}
+
+// QuoteKeys determine if encoder will always quote object keys,
+// default behavior is to only quote keys that are not valid identifier
+func (enc *Encoder) QuoteKeys(quote bool) {
+	enc.quoteKeys = quote
+}
+
+// SetQuote set quote character for strings and quoted keys,
+// valid quote characters are double quote, single quote, and back-tick
+func (enc *Encoder) SetQuote(quote rune) {
+	switch quote {
+	case '"', '\'', '`':
+		enc.quote = quote
+	}
+}
+
+// Encode writes the JSON6 encoding of v to the stream, followed by a newline character
+func (enc *Encoder) Encode(v interface{}) error {
+	e := enc.newEncodeState()
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		return err
+	}
+
+	e.WriteByte('\n')
+	_, err := enc.w.Write(e.Bytes())
+
+	return err
+}
+
+func (enc *Encoder) newEncodeState() *encodeState {
+	return &encodeState{
+		prefix:        enc.prefix,
+		indent:        enc.indent,
+		trailingComma: enc.trailingComma,
+		quoteKeys:     enc.quoteKeys,
+		quote:         enc.quote,
+	}
+}
+
+// encodeState encode Go value into JSON6 text
+type encodeState struct {
+	bytes.Buffer
+	prefix        string
+	indent        string
+	trailingComma bool
+	quoteKeys     bool
+	quote         rune
+	ptrLevel      int                 // count of nested pointers, maps, and slices being encoded
+	ptrSeen       map[ptrKey]struct{} // pointers, maps, and slices being encoded, tracked once ptrLevel is deep
+}
+
+// ptrKey identify pointer, map, or slice for cycle detection
+type ptrKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// startDetectingCyclesAfter is the ptrLevel to start tracking pointers,
+// so encoding values without cycle does not pay the cost
+const startDetectingCyclesAfter = 1000
+
+// Marshal returns the JSON6 encoding of v
+func Marshal(v interface{}) ([]byte, error) {
+	e := NewEncoder(nil).newEncodeState()
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+
+	return e.Bytes(), nil
+}
+
+// MarshalIndent is like Marshal but applies indentation to format the output
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	enc := NewEncoder(nil)
+	enc.SetIndent(prefix, indent)
+	e := enc.newEncodeState()
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+
+	return e.Bytes(), nil
+}
+
+func (e *encodeState) encode(v reflect.Value, depth int) error {
+	if !v.IsValid() {
+		e.WriteString("null")
+		return nil
+	}
+
+	if v.Type() == nodeType {
+		n := v.Interface().(Node)
+		return e.encodeNode(&n, depth)
+	}
+
+	switch v.Type() {
+	case fieldType:
+		return e.encodePresenceField(v, depth)
+
+	case undefinedType, holeType:
+		e.WriteString("undefined")
+		return nil
+
+	case numberType:
+		n := Number(v.String())
+		if n == "" {
+			n = "0"
+		}
+
+		if !isValidNumber(n) {
+			return errInvalidNumber(string(n))
+		}
+
+		e.WriteString(string(n))
+		return nil
+
+	case bigIntType:
+		i := v.Interface().(big.Int)
+		e.WriteString(i.String())
+		return nil
+
+	case bigFloatType:
+		f := v.Interface().(big.Float)
+		e.writeBigFloat(&f)
+		return nil
+	}
+
+	if handled, err := e.encodeMarshaler(v, depth); handled {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.WriteString(strconv.FormatBool(v.Bool()))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.WriteString(strconv.FormatInt(v.Int(), 10))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.WriteString(strconv.FormatUint(v.Uint(), 10))
+
+	case reflect.Float32:
+		e.writeFloat(v.Float(), 32)
+
+	case reflect.Float64:
+		e.writeFloat(v.Float(), 64)
+
+	case reflect.String:
+		e.writeString(v.String())
+
+	case reflect.Interface:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		return e.encode(v.Elem(), depth)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		return e.encodePtr(v, depth, func() error { return e.encode(v.Elem(), depth) })
+
+	case reflect.Struct:
+		return e.encodeStruct(v, depth)
+
+	case reflect.Map:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		return e.encodePtr(v, depth, func() error { return e.encodeMap(v, depth) })
+
+	case reflect.Slice:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		return e.encodePtr(v, depth, func() error { return e.encodeArray(v, depth) })
+
+	case reflect.Array:
+		return e.encodeArray(v, depth)
+
+	default:
+		return errUnsupportedType(v.Type())
+	}
+
+	return nil
+}
+
+// encodePtr call encode with pointer, map, or slice v, return error if v is already being encoded
+func (e *encodeState) encodePtr(v reflect.Value, depth int, encode func() error) error {
+	e.ptrLevel++
+	defer func() { e.ptrLevel-- }()
+	if e.ptrLevel <= startDetectingCyclesAfter {
+		return encode()
+	}
+
+	key := ptrKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+
+	if _, ok := e.ptrSeen[key]; ok {
+		return errCycle(v.Type())
+	}
+
+	if e.ptrSeen == nil {
+		e.ptrSeen = make(map[ptrKey]struct{})
+	}
+
+	e.ptrSeen[key] = struct{}{}
+	defer delete(e.ptrSeen, key)
+
+	return encode()
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// implementsMarshaler check if t implement Marshaler, json.Marshaler, or encoding.TextMarshaler
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// encodeMarshaler encode v with Marshaler, json.Marshaler, or encoding.TextMarshaler, in that order,
+// implemented by v or by pointer to v if v is addressable. It return false if v implement none of them
+func (e *encodeState) encodeMarshaler(v reflect.Value, depth int) (bool, error) {
+	if v.Kind() == reflect.Interface || !v.CanInterface() {
+		return false, nil
+	}
+
+	// big numbers are encoded as numbers, not by their marshalers
+	if v.Kind() == reflect.Ptr && (v.Type().Elem() == bigIntType || v.Type().Elem() == bigFloatType) {
+		return false, nil
+	}
+
+	if v.Kind() != reflect.Ptr && v.CanAddr() && !implementsMarshaler(v.Type()) && implementsMarshaler(v.Addr().Type()) {
+		v = v.Addr()
+	}
+
+	if !implementsMarshaler(v.Type()) {
+		return false, nil
+	}
+
+	// nil pointer is null, like any other nil pointer
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.WriteString("null")
+		return true, nil
+	}
+
+	switch m := v.Interface().(type) {
+	case Marshaler:
+		src, err := m.MarshalJSON6()
+		if err != nil {
+			return true, errMarshaler(v.Type(), err)
+		}
+
+		return true, e.encodeMarshaled(v.Type(), src, depth)
+
+	case json.Marshaler:
+		src, err := m.MarshalJSON()
+		if err != nil {
+			return true, errMarshaler(v.Type(), err)
+		}
+
+		return true, e.encodeMarshaled(v.Type(), src, depth)
+
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			return true, errMarshaler(v.Type(), err)
+		}
+
+		e.writeString(string(text))
+	}
+
+	return true, nil
+}
+
+// encodeMarshaled decode src, the output of marshaler of type t, and write it in the style of e
+func (e *encodeState) encodeMarshaled(t reflect.Type, src []byte, depth int) error {
+	dec := &decoder{lx: NewLexer(bytes.NewReader(src))}
+	if err := dec.readValue(); err != nil {
+		return errMarshaler(t, err)
+	}
+
+	e.encodeValue(&dec.val, depth)
+
+	return nil
+}
+
+// encodeValue write decoded val, numbers are written as they are in source so they keep their precision
+func (e *encodeState) encodeValue(val *value, depth int) {
+	switch val.t {
+	case valueString:
+		e.writeString(val.strVal)
+
+	case valueInteger, valueDouble:
+		e.WriteString(string(val.rnReader.chars))
+
+	case valueBoolean:
+		e.WriteString(strconv.FormatBool(val.boolVal))
+
+	case valueNull:
+		e.WriteString("null")
+
+	case valueUndefined, valueHole:
+		e.WriteString("undefined")
+
+	case valueObject:
+		e.WriteByte('{')
+		for i, k := range val.keys {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+
+			e.writeNewline(depth + 1)
+			e.writeKey(k)
+			v := val.objVal[k]
+			e.encodeValue(&v, depth+1)
+		}
+
+		e.closeContainer('}', depth, len(val.keys) == 0)
+
+	case valueArray:
+		e.WriteByte('[')
+		for i := range val.arrVal {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+
+			e.writeNewline(depth + 1)
+			if val.arrVal[i].t != valueHole {
+				e.encodeValue(&val.arrVal[i], depth+1)
+			}
+		}
+
+		// empty last element must be followed by comma, otherwise the comma before it is read as trailing comma
+		if last := len(val.arrVal) - 1; last >= 0 && !e.trailingComma && val.arrVal[last].t == valueHole {
+			e.WriteByte(',')
+		}
+
+		e.closeContainer(']', depth, len(val.arrVal) == 0)
+	}
+}
+
+func (e *encodeState) encodeStruct(v reflect.Value, depth int) error {
+	e.WriteByte('{')
+	first := true
+	for _, f := range structFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index, false)
+		if !ok || isMissingField(fv) || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		if !first {
+			e.WriteByte(',')
+		}
+
+		first = false
+		e.writeNewline(depth + 1)
+		e.writeKey(f.name)
+		if f.asString {
+			if err := e.encodeQuoted(fv); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := e.encode(fv, depth+1); err != nil {
+			return err
+		}
+	}
+
+	e.closeContainer('}', depth, first)
+
+	return nil
+}
+
+// encodeQuoted encode v as quoted string, used for field with ",string" tag option
+func (e *encodeState) encodeQuoted(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	sub := &encodeState{quote: e.quote}
+	if err := sub.encode(v, 0); err != nil {
+		return err
+	}
+
+	e.writeString(sub.String())
+
+	return nil
+}
+
+// isEmptyValue check if v is empty value for ",omitempty" tag option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+
+	case reflect.Bool:
+		return !v.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func (e *encodeState) encodeMap(v reflect.Value, depth int) error {
+	var keys []string
+	storeKeys := make(map[string]reflect.Value)
+	for _, k := range v.MapKeys() {
+		key, err := mapKeyString(k)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+		storeKeys[key] = k
+	}
+
+	// sort keys so the output is deterministic
+	sort.Strings(keys)
+
+	e.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+
+		e.writeNewline(depth + 1)
+		e.writeKey(key)
+		if err := e.encode(v.MapIndex(storeKeys[key]), depth+1); err != nil {
+			return err
+		}
+	}
+
+	e.closeContainer('}', depth, len(keys) == 0)
+
+	return nil
+}
+
+// mapKeyString convert map key k into object key, the opposite of mapKey.
+// encoding.TextMarshaler take precedence, like encoding.TextUnmarshaler when decoding
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Type().Implements(textMarshalerType) && k.CanInterface() {
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", errMarshaler(k.Type(), err)
+		}
+
+		return string(text), nil
+	}
+
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+
+	return "", errUnsupportedMapKey(k.Type())
+}
+
+func (e *encodeState) encodeArray(v reflect.Value, depth int) error {
+	e.WriteByte('[')
+	n := v.Len()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+
+		e.writeNewline(depth + 1)
+		if isHole(v.Index(i)) {
+			continue
+		}
+
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+
+	// empty last element must be followed by comma, otherwise the comma before it is read as trailing comma
+	if n > 0 && !e.trailingComma && isHole(v.Index(n-1)) {
+		e.WriteByte(',')
+	}
+
+	e.closeContainer(']', depth, n == 0)
+
+	return nil
+}
+
+// encodeNode encode n, object members are written in their order
+func (e *encodeState) encodeNode(n *Node, depth int) error {
+	if n == nil {
+		e.WriteString("null")
+		return nil
+	}
+
+	switch n.Kind {
+	case KindNull:
+		e.WriteString("null")
+
+	case KindUndefined, KindHole:
+		e.WriteString("undefined")
+
+	case KindBool:
+		e.WriteString(strconv.FormatBool(n.Bool))
+
+	case KindInteger:
+		if n.BigInt != nil {
+			e.WriteString(n.BigInt.String())
+		} else {
+			e.WriteString(strconv.FormatInt(n.Int, 10))
+		}
+
+	case KindDouble:
+		if n.BigFloat != nil {
+			e.writeBigFloat(n.BigFloat)
+		} else {
+			e.writeFloat(n.Float, 64)
+		}
+
+	case KindString:
+		e.writeString(n.Str)
+
+	case KindObject:
+		e.WriteByte('{')
+		for i, member := range n.Members {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+
+			e.writeNewline(depth + 1)
+			e.writeKey(member.Key)
+			if err := e.encodeNode(member.Value, depth+1); err != nil {
+				return err
+			}
+		}
+
+		e.closeContainer('}', depth, len(n.Members) == 0)
+
+	case KindArray:
+		e.WriteByte('[')
+		for i, elem := range n.Elems {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+
+			e.writeNewline(depth + 1)
+			if elem != nil && elem.Kind == KindHole {
+				continue
+			}
+
+			if err := e.encodeNode(elem, depth+1); err != nil {
+				return err
+			}
+		}
+
+		// empty last element must be followed by comma, otherwise the comma before it is read as trailing comma
+		if last := len(n.Elems) - 1; last >= 0 && !e.trailingComma && n.Elems[last] != nil && n.Elems[last].Kind == KindHole {
+			e.WriteByte(',')
+		}
+
+		e.closeContainer(']', depth, len(n.Elems) == 0)
+
+	default:
+		return errInvalidNodeKind(n.Kind)
+	}
+
+	return nil
+}
+
+// closeContainer write trailing comma (if enabled) and closing bracket of object or array
+func (e *encodeState) closeContainer(closeChar byte, depth int, empty bool) {
+	if !empty {
+		if e.trailingComma {
+			e.WriteByte(',')
+		}
+
+		e.writeNewline(depth)
+	}
+
+	e.WriteByte(closeChar)
+}
+
+// writeNewline write newline, prefix and indentation if indentation is enabled
+func (e *encodeState) writeNewline(depth int) {
+	if e.prefix == "" && e.indent == "" {
+		return
+	}
+
+	e.WriteByte('\n')
+	e.WriteString(e.prefix)
+	for i := 0; i < depth; i++ {
+		e.WriteString(e.indent)
+	}
+}
+
+// writeKey write object key followed by colon, key will be unquoted if it's valid identifier
+func (e *encodeState) writeKey(key string) {
+	if !e.quoteKeys && isValidIdentifier(key) {
+		e.WriteString(key)
+	} else {
+		e.writeString(key)
+	}
+
+	e.WriteByte(':')
+	if e.prefix != "" || e.indent != "" {
+		e.WriteByte(' ')
+	}
+}
+
+func (e *encodeState) writeFloat(f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		e.WriteString("NaN")
+		return
+
+	case math.IsInf(f, 1):
+		e.WriteString("Infinity")
+		return
+
+	case math.IsInf(f, -1):
+		e.WriteString("-Infinity")
+		return
+	}
+
+	str := strconv.FormatFloat(f, 'g', -1, bitSize)
+	// make sure float is not decoded back as integer
+	if !strings.ContainsAny(str, ".eE") {
+		str += ".0"
+	}
+
+	e.WriteString(str)
+}
+
+// writeString write quoted string with escaped characters
+func (e *encodeState) writeString(str string) {
+	e.WriteRune(e.quote)
+	for _, char := range str {
+		switch char {
+		case '\\':
+			e.WriteString(`\\`)
+
+		case e.quote:
+			e.WriteByte('\\')
+			e.WriteRune(char)
+
+		case '\b':
+			e.WriteString(`\b`)
+
+		case '\f':
+			e.WriteString(`\f`)
+
+		case '\n':
+			e.WriteString(`\n`)
+
+		case '\r':
+			e.WriteString(`\r`)
+
+		case '\t':
+			e.WriteString(`\t`)
+
+		case '\v':
+			e.WriteString(`\v`)
+
+		// line separator and paragraph separator is treated as new line by reader
+		case '\u2028':
+			e.WriteString(`\u2028`)
+
+		case '\u2029':
+			e.WriteString(`\u2029`)
+
+		default:
+			if char < 0x20 || char == 0x7f {
+				e.WriteString(`\x`)
+				e.WriteString(strconv.FormatInt(int64(char)>>4, 16))
+				e.WriteString(strconv.FormatInt(int64(char)&0xf, 16))
+				continue
+			}
+
+			e.WriteRune(char)
+		}
+	}
+
+	e.WriteRune(e.quote)
+}
+
+// keywords can not be used as unquoted object key
+var keywords = map[string]bool{
+	"true":      true,
+	"false":     true,
+	"null":      true,
+	"undefined": true,
+	"NaN":       true,
+	"Infinity":  true,
+}
+
+// isValidIdentifier check if str can be written as unquoted object key
+func isValidIdentifier(str string) bool {
+	if str == "" || keywords[str] || !utf8.ValidString(str) {
+		return false
+	}
+
+	for i, char := range str {
+		if char == '$' || char == '_' {
+			continue
+		}
+
+		if unicode.In(char, unicode.Lu, unicode.Ll, unicode.Lt, unicode.Lm, unicode.Lo, unicode.Nl) {
+			continue
+		}
+
+		if i > 0 && unicode.In(char, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,405 @@
+package json6
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalScalar(t *testing.T) {
+	inputs := []interface{}{
+		nil,
+		true,
+		-123,
+		uint8(255),
+		1.5,
+		2.0,
+		math.Inf(1),
+		math.Inf(-1),
+		"string with \"quote\" and\nnew line",
+	}
+
+	expects := []string{
+		"null",
+		"true",
+		"-123",
+		"255",
+		"1.5",
+		"2.0",
+		"Infinity",
+		"-Infinity",
+		`"string with \"quote\" and\nnew line"`,
+	}
+
+	for i, input := range inputs {
+		byts, err := Marshal(input)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if string(byts) != expects[i] {
+			t.Errorf("unexpected %s, expecting %s", string(byts), expects[i])
+		}
+	}
+}
+
+func TestMarshalObject(t *testing.T) {
+	val := Profile{
+		Name:    "Franklin Collin Tamboto",
+		Age:     21,
+		Sex:     "L",
+		Address: "Bandung, Jawa Barat",
+		CurrentJob: CurrentJob{
+			Title:   "Golang Developer",
+			Company: "PT. Dwidasa Samsara Indonesia",
+			Year:    1,
+		},
+	}
+
+	expected := `{name:"Franklin Collin Tamboto",age:21,Sex:"L",address:"Bandung, Jawa Barat",currentJob:{title:"Golang Developer",company:"PT. Dwidasa Samsara Indonesia",year:1}}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+		return
+	}
+
+	var decVal Profile
+	if err := Unmarshal(byts, &decVal); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if decVal != val {
+		t.Errorf("unexpected %#v, expecting %#v", decVal, val)
+	}
+}
+
+func TestMarshalMapQuotedKeys(t *testing.T) {
+	val := map[string]interface{}{
+		"ident":      1,
+		"not ident":  2,
+		"true":       3,
+		"$_valid123": 4,
+		"1invalid":   5,
+	}
+
+	expected := `{$_valid123:4,"1invalid":5,ident:1,"not ident":2,"true":3}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	val := map[string]interface{}{
+		"list":  []int{1, 2},
+		"empty": []int{},
+		"obj":   map[string]interface{}{},
+	}
+
+	expected := `{
+	empty: [],
+	list: [
+		1,
+		2
+	],
+	obj: {}
+}`
+
+	byts, err := MarshalIndent(val, "", "\t")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+func TestEncoderOptions(t *testing.T) {
+	val := map[string]interface{}{
+		"name": "it's",
+		"list": []string{"a"},
+	}
+
+	expected := `{
+  'list': [
+    'a',
+  ],
+  'name': 'it\'s',
+}
+`
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetQuote('\'')
+	enc.QuoteKeys(true)
+	enc.TrailingComma(true)
+	if err := enc.Encode(val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if buf.String() != expected {
+		t.Errorf("unexpected %s, expecting %s", buf.String(), expected)
+		return
+	}
+
+	decVal := make(map[string]interface{})
+	if err := Unmarshal(buf.Bytes(), &decVal); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if decVal["name"] != "it's" {
+		t.Errorf("unexpected %v, expecting %s", decVal["name"], "it's")
+	}
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Error("expecting unsupported type error")
+	}
+}
+
+func TestMarshalBigNumber(t *testing.T) {
+	hex, _ := new(big.Int).SetString("-deadbeefdeadbeefff", 16)
+	val := struct {
+		Int   *big.Int
+		Float big.Float
+		Inf   *big.Float
+		Num   Number
+		Zero  Number
+	}{
+		Int:   hex,
+		Float: *big.NewFloat(2),
+		Inf:   new(big.Float).SetInf(false),
+		Num:   "1.5e300",
+	}
+
+	expected := `{Int:-4107696892117333766143,Float:2.0,Inf:Infinity,Num:1.5e300,Zero:0}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+
+	if _, err := Marshal(Number("1.5 }")); err == nil {
+		t.Error("expecting invalid number error")
+	}
+}
+
+func TestMarshalField(t *testing.T) {
+	val := struct {
+		Timeout Field
+		Retries Field
+		Proxy   Field
+		Host    Field
+		Any     interface{}
+	}{
+		Timeout: Field{State: FieldSet, Value: 30},
+		Retries: Field{State: FieldNull},
+		Proxy:   Field{State: FieldUndefined, Value: "ignored"},
+		Any:     Undefined,
+	}
+
+	expected := `{Timeout:30,Retries:null,Proxy:undefined,Any:undefined}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+func TestMarshalArrayHoles(t *testing.T) {
+	var val interface{}
+	if err := Unmarshal([]byte(`['test',,,'one',,]`), &val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	var node Node
+	if err := Unmarshal([]byte(`['test',,,'one',,]`), &node); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	for _, v := range []interface{}{val, node} {
+		expected := `["test",,,"one",,]`
+		byts, err := Marshal(v)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if string(byts) != expected {
+			t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+		}
+	}
+
+	expected := "[\n\t1,\n\t,\n]"
+	byts, err := MarshalIndent([]interface{}{1, Hole}, "", "\t")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(byts), expected)
+	}
+}
+
+func TestMarshalTagOptions(t *testing.T) {
+	val := tagOptionsVal{
+		Base:   tagBase{ID: 7},
+		Secret: "x",
+		Count:  16,
+	}
+
+	expected := `{id:7,count:"16",enabled:null}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+func TestMarshalEmbeddedStruct(t *testing.T) {
+	val := embeddedPtrConfig{Port: 80}
+	expected := `{port:80}`
+	byts, err := Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+
+	val.BaseConfig = &BaseConfig{Name: "app", Version: 1}
+	expected = `{name:"app",version:1,port:80}`
+	byts, err = Marshal(val)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v version) MarshalJSON6() ([]byte, error) {
+	return []byte(fmt.Sprintf("{/* semver */ major: %d, minor: 0x%x}", v.major, v.minor)), nil
+}
+
+type hostKey string
+
+func (k hostKey) MarshalText() ([]byte, error) {
+	return []byte("host-" + string(k)), nil
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON6() ([]byte, error) {
+	return nil, errors.New("failed")
+}
+
+func TestMarshalMarshaler(t *testing.T) {
+	type release struct {
+		Version version         `json6:"version"`
+		Date    time.Time       `json6:"date"`
+		Addr    net.IP          `json6:"addr"`
+		Next    *time.Time      `json6:"next"`
+		Hosts   map[hostKey]int `json6:"hosts"`
+	}
+
+	val := release{
+		Version: version{major: 1, minor: 16},
+		Date:    time.Date(2020, 11, 2, 15, 4, 5, 0, time.UTC),
+		Addr:    net.IPv4(10, 0, 0, 1),
+		Hosts:   map[hostKey]int{"a": 1},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetQuote('\'')
+	if err := enc.Encode(val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	expected := "{version:{major:1,minor:0x10},date:'2020-11-02T15:04:05Z',addr:'10.0.0.1',next:null,hosts:{'host-a':1}}\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected %s, expecting %s", buf.String(), expected)
+	}
+
+	// time.Time and net.IP are decoded back by their unmarshalers
+	var decoded release
+	if err := Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if !decoded.Date.Equal(val.Date) || !decoded.Addr.Equal(val.Addr) {
+		t.Errorf("unexpected %#v, expecting %#v", decoded, val)
+	}
+
+	if _, err := Marshal(failingMarshaler{}); err == nil {
+		t.Error("expecting error from MarshalJSON6")
+	}
+}
+
+type cyclicNode struct {
+	Next *cyclicNode `json6:"next"`
+}
+
+func TestMarshalCycle(t *testing.T) {
+	node := &cyclicNode{}
+	node.Next = node
+	if _, err := Marshal(node); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("unexpected %v, expecting cycle error", err)
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := Marshal(m); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("unexpected %v, expecting cycle error", err)
+	}
+}
@@ -3,54 +3,385 @@ package json6
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"unicode"
 )
 
-func errInvalidChar(invChar rune, pos *Position, nearChars []rune, expecting string) error {
-	return fmt.Errorf("invalid character '%s' at %d:%d near '%s', expecting %s", string([]rune{invChar}), pos.Line(), pos.Column(), string(nearChars), expecting)
+// SyntaxError describes invalid JSON6 syntax, found either by the Lexer or the decoder
+type SyntaxError struct {
+	Msg       string   // description of the error
+	Pos       Position // position of the error
+	Near      string   // characters near the error, can be empty
+	Expecting []string // what was expected at Pos
+	Err       error    // underlying error, can be nil
 }
 
-func errUnexpectedEOF(pos *Position, expecting string) error {
-	return fmt.Errorf("unexpected EOF at %d:%d, expecting %s", pos.Line(), pos.Column(), expecting)
+func (e *SyntaxError) Error() string {
+	msg := fmt.Sprintf("%s at %d:%d", e.Msg, e.Pos.Line(), e.Pos.Column())
+	if e.Near != "" {
+		msg += fmt.Sprintf(" near '%s'", e.Near)
+	}
+
+	if len(e.Expecting) > 0 {
+		msg += ", expecting " + joinExpects(e.Expecting)
+	}
+
+	return msg
 }
 
-func errUnexpectedToken(token Token, expects ...string) error {
-	expectsLen := len(expects)
-	var expectStr string
-	if expectsLen > 1 {
-		for i, expect := range expects {
-			if i < expectsLen-1 {
-				expectStr += expect + ", "
-			} else {
-				expectStr += "or " + expect
-			}
-		}
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalTypeError describes a JSON6 value that can not be decoded to a Go type
+type UnmarshalTypeError struct {
+	Value     string       // source of the JSON6 value, empty for object and array
+	JSON6Type string       // JSON6 value type, like "string", "integer", or "object"
+	GoType    reflect.Type // type of Go value it could not be decoded to
+	Path      string       // JSON path to the value, like "$.services[3].port"
+	Pos       Position     // position of the value
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	msg := "can not decode "
+	if e.Value != "" {
+		msg += fmt.Sprintf("%s (%s)", e.Value, e.JSON6Type)
 	} else {
-		expectStr = expects[0]
+		msg += e.JSON6Type
+	}
+
+	msg += " to type " + e.GoType.String()
+	if e.Path != "" {
+		msg += " at path " + e.Path
+	}
+
+	if e.Pos.Line() > 0 {
+		msg += fmt.Sprintf(" at %d:%d", e.Pos.Line(), e.Pos.Column())
+	}
+
+	return msg
+}
+
+// UnknownFieldError describes an object key that has no matching struct field,
+// returned only if unknown fields are disallowed
+type UnknownFieldError struct {
+	Key    string       // the unknown object key
+	GoType reflect.Type // struct type the object is decoded to
+	Path   string       // JSON path to the object, like "$.services[3]"
+	Pos    Position     // position of the key
+}
+
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("unknown field %s in type %s", strconv.Quote(e.Key), e.GoType.String())
+	if e.Path != "" {
+		msg += " at path " + e.Path
+	}
+
+	if e.Pos.Line() > 0 {
+		msg += fmt.Sprintf(" at %d:%d", e.Pos.Line(), e.Pos.Column())
+	}
+
+	return msg
+}
+
+// DuplicateKeyError describes a key that appear more than once in an object,
+// returned only if duplicate keys are rejected
+type DuplicateKeyError struct {
+	Key      string   // the repeated key
+	FirstPos Position // position of the first occurrence
+	Pos      Position // position of the repeated occurrence
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key %s at %d:%d, first defined at %d:%d",
+		strconv.Quote(e.Key), e.Pos.Line(), e.Pos.Column(), e.FirstPos.Line(), e.FirstPos.Column())
+}
+
+// ArrayHoleError describes an empty array element, like the second element of [1,,3],
+// returned only if array holes are rejected
+type ArrayHoleError struct {
+	Index int      // index of the element
+	Pos   Position // position of the comma after the element
+}
+
+func (e *ArrayHoleError) Error() string {
+	return fmt.Sprintf("empty array element at index %d, at %d:%d", e.Index, e.Pos.Line(), e.Pos.Column())
+}
+
+// EmbeddedPointerError describes an object key matching a field promoted through a nil embedded pointer
+// to unexported struct, the decoder can not allocate the unexported struct
+type EmbeddedPointerError struct {
+	Key    string       // the object key
+	GoType reflect.Type // struct type the object is decoded to
+	Field  string       // name of the field of the key
+	Path   string       // JSON path to the object, like "$.services[3]"
+	Pos    Position     // position of the key
+}
+
+func (e *EmbeddedPointerError) Error() string {
+	msg := fmt.Sprintf("can not set field %s.%s, embedded pointer to unexported struct is nil", e.GoType.String(), e.Field)
+	if e.Path != "" {
+		msg += " at path " + e.Path
 	}
 
-	return fmt.Errorf("unexpected token '%s' (%s) at %d:%d, expecting %s", token.String(), token.TypeString(), token.StartPos.ln, token.StartPos.col, expectStr)
+	if e.Pos.Line() > 0 {
+		msg += fmt.Sprintf(" at %d:%d", e.Pos.Line(), e.Pos.Column())
+	}
+
+	return msg
+}
+
+// MarshalerError describes an error returned by MarshalJSON6, MarshalJSON, or MarshalText,
+// or invalid JSON6 returned by MarshalJSON6 or MarshalJSON
+type MarshalerError struct {
+	GoType reflect.Type // type of the value being encoded
+	Err    error        // error of the marshaler, or syntax error of its output
 }
 
-func errUnexpectedEndOfTokenStream(expects ...string) error {
+func (e *MarshalerError) Error() string {
+	return fmt.Sprintf("can not encode value of type %s, marshaler failed: %s", e.GoType.String(), e.Err.Error())
+}
+
+func (e *MarshalerError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedTypeError describes a Go type that can not be encoded
+type UnsupportedTypeError struct {
+	GoType reflect.Type
+	MapKey bool // the type is a map key type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e.MapKey {
+		return "can not encode map key of type " + e.GoType.String()
+	}
+
+	return "can not encode value of type " + e.GoType.String()
+}
+
+// UnsupportedValueError describes a Go value that can not be encoded, like a cycle or invalid Number
+type UnsupportedValueError struct {
+	GoType reflect.Type // type of the value
+	Msg    string       // why the value can not be encoded
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return fmt.Sprintf("can not encode value of type %s, %s", e.GoType.String(), e.Msg)
+}
+
+// joinExpects join expected things into "a, b, or c"
+func joinExpects(expects []string) string {
 	expectsLen := len(expects)
+	if expectsLen == 1 {
+		return expects[0]
+	}
+
 	var expectStr string
-	if expectsLen > 1 {
-		for i, expect := range expects {
-			if i < expectsLen-1 {
-				expectStr += expect + ", "
-			} else {
-				expectStr += "or " + expect
-			}
+	for i, expect := range expects {
+		if i < expectsLen-1 {
+			expectStr += expect + ", "
+		} else {
+			expectStr += "or " + expect
 		}
-	} else {
-		expectStr = expects[0]
 	}
 
-	return fmt.Errorf("unexpected end of token stream, expecting %s", expectStr)
+	return expectStr
+}
+
+func errInvalidChar(invChar rune, pos *Position, nearChars []rune, expecting string) error {
+	return &SyntaxError{
+		Msg:       fmt.Sprintf("invalid character '%s'", string([]rune{invChar})),
+		Pos:       *pos,
+		Near:      string(nearChars),
+		Expecting: []string{expecting},
+	}
+}
+
+func errUnexpectedEOF(pos *Position, expecting string) error {
+	return &SyntaxError{
+		Msg:       "unexpected EOF",
+		Pos:       *pos,
+		Expecting: []string{expecting},
+	}
+}
+
+func errUnexpectedToken(token Token, expects ...string) error {
+	return &SyntaxError{
+		Msg:       fmt.Sprintf("unexpected %s", token.TypeString()),
+		Pos:       *token.StartPos,
+		Near:      token.String(),
+		Expecting: expects,
+	}
+}
+
+func errUnexpectedEndOfTokenStream(pos *Position, expects ...string) error {
+	return &SyntaxError{
+		Msg:       "unexpected end of token stream",
+		Pos:       *pos,
+		Expecting: expects,
+	}
+}
+
+// errInvalidToken is returned when characters of a token can not be decoded,
+// like integer that overflow int64 or invalid escape sequence
+func errInvalidToken(token Token, err error) error {
+	cause := err
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		cause = numErr.Err
+	}
+
+	return &SyntaxError{
+		Msg:  fmt.Sprintf("invalid %s (%s)", token.TypeString(), cause.Error()),
+		Pos:  *token.StartPos,
+		Near: token.String(),
+		Err:  err,
+	}
+}
+
+func errMaxDepth(token Token) error {
+	return &SyntaxError{
+		Msg:  fmt.Sprintf("exceeded max nesting depth of %d", maxNestingDepth),
+		Pos:  *token.StartPos,
+		Near: token.String(),
+	}
+}
+
+func errMismatchType(val *value, valType reflect.Type) error {
+	err := &UnmarshalTypeError{
+		JSON6Type: getValTypeStr(*val),
+		GoType:    valType,
+	}
+
+	if val.t != valueObject && val.t != valueArray && val.rnReader != nil {
+		err.Value = string(val.rnReader.chars)
+	}
+
+	if val.pos != nil {
+		err.Pos = *val.pos
+	}
+
+	return err
+}
+
+func errMismatchKeyType(key string, keyType reflect.Type) error {
+	return &UnmarshalTypeError{
+		Value:     strconv.Quote(key),
+		JSON6Type: "object key",
+		GoType:    keyType,
+	}
+}
+
+func errUnknownField(key string, pos *Position, structType reflect.Type, path string) error {
+	err := &UnknownFieldError{
+		Key:    key,
+		GoType: structType,
+		Path:   path,
+	}
+
+	if pos != nil {
+		err.Pos = *pos
+	}
+
+	return err
+}
+
+func errDuplicateKey(key string, firstPos, pos *Position) error {
+	return &DuplicateKeyError{
+		Key:      key,
+		FirstPos: *firstPos,
+		Pos:      *pos,
+	}
+}
+
+func errSetEmbeddedPtr(key string, pos *Position, structType reflect.Type, fieldName, path string) error {
+	err := &EmbeddedPointerError{
+		Key:    key,
+		GoType: structType,
+		Field:  fieldName,
+		Path:   path,
+	}
+
+	if pos != nil {
+		err.Pos = *pos
+	}
+
+	return err
+}
+
+// errInvalidJSONNumber is returned by ToJSON for NaN and Infinity, which JSON can not represent
+func errInvalidJSONNumber(src []rune, pos *Position) error {
+	err := &SyntaxError{
+		Msg:  "JSON number can not be NaN or Infinity",
+		Near: string(src),
+	}
+
+	if pos != nil {
+		err.Pos = *pos
+	}
+
+	return err
+}
+
+func errArrayHole(index int, pos *Position) error {
+	return &ArrayHoleError{
+		Index: index,
+		Pos:   *pos,
+	}
 }
 
-func errMismatchType(src []rune, srcType, valType string) error {
-	return fmt.Errorf("can not decode %s (%s) to type %s", string(src), srcType, valType)
+// errLoneSurrogate is wrapped by SyntaxError of the token
+func errLoneSurrogate(char rune) error {
+	return fmt.Errorf("%w \\u%X", ErrLoneSurrogate, char)
+}
+
+func errInvalidCodePoint(pos *Position, nearChars []rune) error {
+	return &SyntaxError{
+		Msg:  fmt.Sprintf("code point out of range, maximum is %X", unicode.MaxRune),
+		Pos:  *pos,
+		Near: string(nearChars),
+	}
+}
+
+func errInvalidNumber(n string) error {
+	return &UnsupportedValueError{
+		GoType: numberType,
+		Msg:    fmt.Sprintf("%q is not a JSON6 number", n),
+	}
+}
+
+func errMarshaler(valType reflect.Type, err error) error {
+	return &MarshalerError{
+		GoType: valType,
+		Err:    err,
+	}
+}
+
+func errCycle(valType reflect.Type) error {
+	return &UnsupportedValueError{
+		GoType: valType,
+		Msg:    "encountered a cycle",
+	}
+}
+
+func errUnsupportedType(valType reflect.Type) error {
+	return &UnsupportedTypeError{GoType: valType}
+}
+
+func errUnsupportedMapKey(keyType reflect.Type) error {
+	return &UnsupportedTypeError{
+		GoType: keyType,
+		MapKey: true,
+	}
+}
+
+func errInvalidNodeKind(kind Kind) error {
+	return &UnsupportedValueError{
+		GoType: nodeType,
+		Msg:    "invalid kind " + strconv.Itoa(int(kind)),
+	}
 }
 
 func errDecodeToNilPtr() error {
@@ -61,5 +392,9 @@ func errDecodeToNonPtr() error {
 	return errors.New("can not decode to non pointer")
 }
 
+// ErrLoneSurrogate is wrapped by SyntaxError of a string or identifier with lone surrogate,
+// returned only if lone surrogates are rejected
+var ErrLoneSurrogate = errors.New("lone surrogate")
+
 var ErrNoMoreToken = errors.New("no more token")
 var ErrAlreadyAtBeginning = errors.New("already at beginning")
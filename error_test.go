@@ -0,0 +1,186 @@
+package json6
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	src := `{
+	a: 1,
+	b: 2 3
+}`
+
+	var val map[string]interface{}
+	err := Unmarshal([]byte(src), &val)
+	var synErr *SyntaxError
+	if !errors.As(err, &synErr) {
+		t.Errorf("unexpected %v, expecting *SyntaxError", err)
+		return
+	}
+
+	if synErr.Pos.Line() != 3 || synErr.Pos.Column() != 7 {
+		t.Errorf("unexpected position %d:%d, expecting %d:%d", synErr.Pos.Line(), synErr.Pos.Column(), 3, 7)
+	}
+
+	if synErr.Near != "3" {
+		t.Errorf("unexpected near %s, expecting %s", synErr.Near, "3")
+	}
+
+	expects := []string{"','", "'}'"}
+	if !reflect.DeepEqual(synErr.Expecting, expects) {
+		t.Errorf("unexpected %v, expecting %v", synErr.Expecting, expects)
+	}
+}
+
+func TestSyntaxErrorInvalidChar(t *testing.T) {
+	var val interface{}
+	err := Unmarshal([]byte("[0x1g]"), &val)
+	var synErr *SyntaxError
+	if !errors.As(err, &synErr) {
+		t.Errorf("unexpected %v, expecting *SyntaxError", err)
+		return
+	}
+
+	if synErr.Pos.Line() != 1 || synErr.Pos.Column() != 5 {
+		t.Errorf("unexpected position %d:%d, expecting %d:%d", synErr.Pos.Line(), synErr.Pos.Column(), 1, 5)
+	}
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	src := `{
+	name: 'name',
+	currentJob: {
+		year: '1'
+	}
+}`
+
+	var val Profile
+	err := Unmarshal([]byte(src), &val)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+		return
+	}
+
+	if typeErr.Pos.Line() != 4 || typeErr.Pos.Column() != 9 {
+		t.Errorf("unexpected position %d:%d, expecting %d:%d", typeErr.Pos.Line(), typeErr.Pos.Column(), 4, 9)
+	}
+
+	if typeErr.JSON6Type != "string" || typeErr.Value != "'1'" {
+		t.Errorf("unexpected %s (%s), expecting %s (%s)", typeErr.Value, typeErr.JSON6Type, "'1'", "string")
+	}
+
+	if typeErr.GoType != reflect.TypeOf(0) {
+		t.Errorf("unexpected Go type %s, expecting %s", typeErr.GoType, reflect.TypeOf(0))
+	}
+
+	if typeErr.Path != "$.currentJob.year" {
+		t.Errorf("unexpected path %s, expecting %s", typeErr.Path, "$.currentJob.year")
+	}
+}
+
+func TestUnmarshalTypeErrorPath(t *testing.T) {
+	inputs := []string{
+		`{services: [{host: 'a', port: 1}, {host: 'b', port: 'x'}]}`,
+		`{services: [], 'by name': {a: {port: true}}}`,
+		`{count: '1.5'}`,
+		`[1, {}]`,
+	}
+
+	expects := []string{"$.services[1].port", "$['by name'].a.port", "$.count", "$[1]"}
+	targets := []func() interface{}{
+		func() interface{} {
+			return new(struct {
+				Services []service `json6:"services"`
+			})
+		},
+		func() interface{} {
+			return new(struct {
+				ByName map[string]service `json6:"by name"`
+			})
+		},
+		func() interface{} {
+			return new(struct {
+				Count int `json6:"count,string"`
+			})
+		},
+		func() interface{} {
+			return new([]int)
+		},
+	}
+
+	for i, input := range inputs {
+		err := Unmarshal([]byte(input), targets[i]())
+		var typeErr *UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("unexpected %v, expecting *UnmarshalTypeError", err)
+			continue
+		}
+
+		if typeErr.Path != expects[i] {
+			t.Errorf("unexpected path %s, expecting %s", typeErr.Path, expects[i])
+		}
+
+		if !strings.Contains(typeErr.Error(), " at path "+expects[i]+" at ") {
+			t.Errorf("unexpected message %q", typeErr.Error())
+		}
+	}
+}
+
+type hiddenBase struct {
+	Name string `json6:"name"`
+}
+
+type hiddenPtrConfig struct {
+	*hiddenBase
+	Port int `json6:"port"`
+}
+
+func TestDecodeErrorTypes(t *testing.T) {
+	var val hiddenPtrConfig
+	err := Unmarshal([]byte(`{port: 80, name: 'app'}`), &val)
+	var ptrErr *EmbeddedPointerError
+	if !errors.As(err, &ptrErr) || ptrErr.Key != "name" || ptrErr.Path != "$" || ptrErr.Pos.Column() != 12 {
+		t.Errorf("unexpected %v, expecting *EmbeddedPointerError of name at 1:12", err)
+	}
+
+	var synErr *SyntaxError
+	if _, err := ToJSON([]byte(`[1, NaN]`)); !errors.As(err, &synErr) || synErr.Pos.Column() != 5 {
+		t.Errorf("unexpected %v, expecting *SyntaxError at 1:5", err)
+	}
+
+	dec := NewDecoder(strings.NewReader(`{'\uD83D': 1}`))
+	dec.LoneSurrogates(LoneSurrogatesReject)
+	var m map[string]int
+	if err := dec.Decode(&m); !errors.As(err, &synErr) || !errors.Is(err, ErrLoneSurrogate) {
+		t.Errorf("unexpected %v, expecting *SyntaxError wrapping ErrLoneSurrogate", err)
+	}
+}
+
+func TestEncodeErrorTypes(t *testing.T) {
+	var marshalerErr *MarshalerError
+	if _, err := Marshal(failingMarshaler{}); !errors.As(err, &marshalerErr) || marshalerErr.Err.Error() != "failed" {
+		t.Errorf("unexpected %v, expecting *MarshalerError", err)
+	}
+
+	var typeErr *UnsupportedTypeError
+	if _, err := Marshal(make(chan int)); !errors.As(err, &typeErr) || typeErr.MapKey {
+		t.Errorf("unexpected %v, expecting *UnsupportedTypeError", err)
+	}
+
+	if _, err := Marshal(map[float64]int{1: 1}); !errors.As(err, &typeErr) || !typeErr.MapKey {
+		t.Errorf("unexpected %v, expecting *UnsupportedTypeError of map key", err)
+	}
+
+	node := &cyclicNode{}
+	node.Next = node
+	var valErr *UnsupportedValueError
+	for _, v := range []interface{}{node, Number("1 }"), Node{Kind: Kind(99)}} {
+		if _, err := Marshal(v); !errors.As(err, &valErr) {
+			t.Errorf("unexpected %v, expecting *UnsupportedValueError", err)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package json6
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// field is a struct field that can be decoded from or encoded to an object member
+type field struct {
+	name      string       // object key
+	goName    string       // name of the field in Go struct
+	index     []int        // index sequence of the field, see reflect.Value.FieldByIndex
+	typ       reflect.Type // type of the field
+	tagged    bool         // name is set by struct tag
+	omitEmpty bool         // omit the member when encoding empty value
+	asString  bool         // number and boolean is encoded as string
+}
+
+// tagOptions is options of a struct tag, the part after the first comma
+type tagOptions string
+
+// Contains check if opt is present in tag options
+func (opts tagOptions) Contains(opt string) bool {
+	for opts != "" {
+		var cur string
+		i := strings.Index(string(opts), ",")
+		if i >= 0 {
+			cur, opts = string(opts[:i]), opts[i+1:]
+		} else {
+			cur, opts = string(opts), ""
+		}
+
+		if cur == opt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTag get the name and options of a struct field tag,
+// accepted tags are json6, json5, and json, in that order of precedence.
+// ok is false if the field should be ignored (tag is "-")
+func parseTag(sf reflect.StructField) (name string, opts tagOptions, ok bool) {
+	tag := sf.Tag.Get("json6")
+	if tag == "" {
+		tag = sf.Tag.Get("json5")
+	}
+
+	if tag == "" {
+		tag = sf.Tag.Get("json")
+	}
+
+	if tag == "-" {
+		return "", "", false
+	}
+
+	name = tag
+	if i := strings.Index(tag, ","); i >= 0 {
+		name, opts = tag[:i], tagOptions(tag[i+1:])
+	}
+
+	return name, opts, true
+}
+
+// structInfo is fields of a struct type, with index of fields by object key
+type structInfo struct {
+	list         []field
+	byName       map[string]int
+	byFoldedName map[string]int // index of the first field by folded object key, see foldName
+}
+
+// fieldCache is cache of struct fields, keyed by struct type
+var fieldCache sync.Map // map[reflect.Type]*structInfo
+
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if info, ok := fieldCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	list := typeFields(t)
+	byName := make(map[string]int, len(list))
+	byFoldedName := make(map[string]int, len(list))
+	for i, f := range list {
+		byName[f.name] = i
+		if _, ok := byFoldedName[foldName(f.name)]; !ok {
+			byFoldedName[foldName(f.name)] = i
+		}
+	}
+
+	info, _ := fieldCache.LoadOrStore(t, &structInfo{list: list, byName: byName, byFoldedName: byFoldedName})
+	return info.(*structInfo)
+}
+
+// structFields get fields of struct type t that can be decoded or encoded, ordered by index sequence.
+// Fields of embedded and inline struct are promoted following encoding/json rules
+func structFields(t reflect.Type) []field {
+	return cachedStructInfo(t).list
+}
+
+// lookupField get field of struct type t by object key.
+// If no field name exactly match and caseSensitive is false, the first field that match case insensitively is returned
+func lookupField(t reflect.Type, name string, caseSensitive bool) (field, bool) {
+	info := cachedStructInfo(t)
+	if i, ok := info.byName[name]; ok {
+		return info.list[i], true
+	}
+
+	if !caseSensitive {
+		if i, ok := info.byFoldedName[foldName(name)]; ok {
+			return info.list[i], true
+		}
+	}
+
+	return field{}, false
+}
+
+// foldName fold case of name for case insensitive lookup, so names equal under strings.EqualFold,
+// like "K" and Kelvin sign, or "S" and long s, are folded to the same string
+func foldName(name string) string {
+	return strings.ToLower(strings.ToUpper(name))
+}
+
+// typeFields walk struct type t breadth first, and resolve fields with the same name
+// by depth and tag precedence, see dominantField
+func typeFields(t reflect.Type) []field {
+	current := []field{}
+	next := []field{{typ: t}}
+
+	// count of queued names for current and next level
+	count := map[reflect.Type]int{}
+	nextCount := map[reflect.Type]int{}
+
+	// types already visited at an earlier level
+	visited := map[reflect.Type]bool{}
+
+	var fields []field
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+
+			visited[f.typ] = true
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				exported := sf.PkgPath == ""
+				name, opts, ok := parseTag(sf)
+				if !ok {
+					continue
+				}
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// embedded struct without name, or struct with ",inline" option is promoted
+				promote := ft.Kind() == reflect.Struct &&
+					((sf.Anonymous && name == "") || (opts.Contains("inline") && (exported || sf.Anonymous)))
+
+				// unexported field can not be set,
+				// but exported fields of unexported embedded struct can
+				if !exported && !promote {
+					continue
+				}
+
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				if promote {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, field{name: ft.Name(), index: index, typ: ft})
+					}
+
+					continue
+				}
+
+				tagged := name != ""
+				if name == "" {
+					name = sf.Name
+				}
+
+				fields = append(fields, field{
+					name:      name,
+					goName:    sf.Name,
+					index:     index,
+					typ:       sf.Type,
+					tagged:    tagged,
+					omitEmpty: opts.Contains("omitempty"),
+					asString:  opts.Contains("string") && isStringableKind(sf.Type),
+				})
+
+				// the same struct is embedded more than once at this level,
+				// add the field twice so it is annihilated by dominantField
+				if count[f.typ] > 1 {
+					fields = append(fields, fields[len(fields)-1])
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+
+		return lessIndex(x[i].index, x[j].index)
+	})
+
+	// drop hidden and conflicting fields
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	return fields
+}
+
+// dominantField get the field that win among fields with the same name,
+// fields must be sorted by depth then tag. The shallowest field win,
+// if there is more than one at that depth the tagged one win, otherwise there is a conflict
+// and ok is false
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+
+	return fields[0], true
+}
+
+// lessIndex compare index sequence of two fields
+func lessIndex(x, y []int) bool {
+	for i, xi := range x {
+		if i >= len(y) {
+			return false
+		}
+
+		if xi != y[i] {
+			return xi < y[i]
+		}
+	}
+
+	return len(x) < len(y)
+}
+
+// isStringableKind check if value of type t can be encoded as string with ",string" tag option
+func isStringableKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+
+	return false
+}
+
+// fieldByIndex get nested field of v by index, nil pointer to embedded or inline struct is allocated if alloc is true.
+// ok is false if a nil pointer is found and can not be allocated
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(idx)
+	}
+
+	return v, true
+}
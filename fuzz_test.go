@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package json6
+
+import "testing"
+
+// FuzzUnmarshal is in its own file because testing.F require Go 1.18
+func FuzzUnmarshal(f *testing.F) {
+	seeds := []string{
+		"-3000",
+		"[1, null, , undefined, 0x4, 0e5, 'str']",
+		`{name: "Franklin", age: 21, Sex: 'L', currentJob: {title: 'dev', year: 1}}`,
+		`{a: {b: [1.5, -Infinity, NaN, .5, 1e10]}, 'c': "\x41B\u{43}"}`,
+		`'line\
+continued'`,
+		"/* comment */ true // comment",
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		var iface interface{}
+		Unmarshal(src, &iface)
+
+		var profile Profile
+		Unmarshal(src, &profile)
+
+		var m map[string]interface{}
+		Unmarshal(src, &m)
+
+		var slice []int
+		Unmarshal(src, &slice)
+
+		var arr [2]string
+		Unmarshal(src, &arr)
+	})
+}
@@ -0,0 +1,333 @@
+// Package format formats JSON6 documents in a canonical style, it is shared by json6fmt and json6-lsp
+package format
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tamboto2000/json6"
+)
+
+// Style is the canonical style of formatted document
+type Style struct {
+	Indent        string // indentation of each nesting level
+	Quote         rune   // quote character of strings and quoted keys
+	TrailingComma bool   // write comma after the last entry of multi-line object and array
+}
+
+// formatter write tokens of a document in canonical style,
+// comments are kept and blank lines between entries are collapsed into one
+type formatter struct {
+	Style
+	src    []byte
+	tokens []json6.Token
+	idx    int // index of the current token
+	depth  int
+	buf    bytes.Buffer
+}
+
+// Format format JSON6 document src, comments are kept and blank lines between entries are collapsed into one
+func Format(src []byte, s Style) ([]byte, error) {
+	// report syntax error with the same messages as the decoder
+	var node json6.Node
+	if err := json6.Unmarshal(src, &node); err != nil {
+		return nil, err
+	}
+
+	f := &formatter{Style: s, src: src}
+	lx := json6.NewLexer(bytes.NewReader(src))
+	for {
+		token, err := lx.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, err
+		}
+
+		f.tokens = append(f.tokens, token)
+	}
+
+	// comments before the root value
+	for f.current().Type() == json6.TokenComment {
+		if f.idx > 0 {
+			f.newline(f.hasBlankLine())
+		}
+
+		f.buf.WriteString(f.current().String())
+		f.idx++
+	}
+
+	if f.idx > 0 {
+		f.newline(f.hasBlankLine())
+	}
+
+	f.formatValue()
+
+	// comments after the root value
+	for f.idx < len(f.tokens) {
+		if f.sameLine() {
+			f.buf.WriteByte(' ')
+		} else {
+			f.newline(f.hasBlankLine())
+		}
+
+		f.buf.WriteString(f.current().String())
+		f.idx++
+	}
+
+	f.buf.WriteByte('\n')
+
+	return f.buf.Bytes(), nil
+}
+
+func (f *formatter) current() json6.Token {
+	return f.tokens[f.idx]
+}
+
+// isPunct check if the current token is punctuator punct
+func (f *formatter) isPunct(punct string) bool {
+	return f.idx < len(f.tokens) && f.current().Type() == json6.TokenPunctuator && f.current().String() == punct
+}
+
+// gap return source between the previous token and the current token
+func (f *formatter) gap() []byte {
+	prev := f.tokens[f.idx-1]
+	return f.src[prev.EndOffset():f.current().StartPos.Offset()]
+}
+
+// sameLine check if the current token is on the same line as the end of the previous token
+func (f *formatter) sameLine() bool {
+	return bytes.IndexByte(f.gap(), '\n') < 0
+}
+
+// hasBlankLine check if there's a blank line between the previous token and the current token
+func (f *formatter) hasBlankLine() bool {
+	return bytes.Count(f.gap(), []byte{'\n'}) >= 2
+}
+
+// newline write newline and indentation, with an empty line before it if blank is true
+func (f *formatter) newline(blank bool) {
+	if blank {
+		f.buf.WriteByte('\n')
+	}
+
+	f.buf.WriteByte('\n')
+	f.buf.WriteString(strings.Repeat(f.Indent, f.depth))
+}
+
+// encode encode v with the quote of the style
+func (f *formatter) encode(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json6.NewEncoder(&buf)
+	enc.SetQuote(f.Quote)
+	enc.Encode(v)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// source return source of token
+func (f *formatter) source(token json6.Token) string {
+	return string(f.src[token.StartPos.Offset():token.EndOffset()])
+}
+
+// decodeString decode string token, ok is false if the string can not be encoded back to the same value,
+// like string with lone surrogate which is decoded as U+FFFD
+func (f *formatter) decodeString(token json6.Token) (str string, ok bool) {
+	src := f.source(token)
+	if !utf8.ValidString(src) {
+		return "", false
+	}
+
+	dec := json6.NewDecoder(strings.NewReader(src))
+	dec.LoneSurrogates(json6.LoneSurrogatesReject)
+	if err := dec.Decode(&str); err != nil {
+		return "", false
+	}
+
+	return str, true
+}
+
+// formatKey write object key, the key is only quoted if it is not a valid identifier
+func (f *formatter) formatKey() {
+	token := f.current()
+	f.idx++
+	// identifier is written from its source, its chars do not keep escapes like \u0041
+	if token.Type() == json6.TokenIdentifier {
+		f.buf.WriteString(f.source(token))
+		return
+	}
+
+	key, ok := f.decodeString(token)
+	if !ok {
+		f.buf.WriteString(f.source(token))
+		return
+	}
+
+	// let the encoder decide if the key must be quoted, "{key:0}"
+	obj := f.encode(map[string]int{key: 0})
+	f.buf.WriteString(obj[1 : len(obj)-len(":0}")])
+}
+
+// formatInlineComments write comments between key and colon, or between colon and value,
+// each comment is preceded by a space, newline return true if the last comment is a line comment
+// so the next token is already on a new line
+func (f *formatter) formatInlineComments() (newline bool) {
+	for f.idx < len(f.tokens) && f.current().Type() == json6.TokenComment {
+		if !newline {
+			f.buf.WriteByte(' ')
+		}
+
+		comment := f.current().String()
+		f.buf.WriteString(comment)
+		f.idx++
+		newline = strings.HasPrefix(comment, "//")
+		if newline {
+			f.depth++
+			f.newline(false)
+			f.depth--
+		}
+	}
+
+	return newline
+}
+
+func (f *formatter) formatValue() {
+	token := f.current()
+	switch token.Type() {
+	case json6.TokenPunctuator:
+		switch token.String() {
+		case "{":
+			f.formatContainer("}", true)
+		case "[":
+			f.formatContainer("]", false)
+		}
+
+	case json6.TokenString:
+		// string that can not be encoded back to the same value is kept as is
+		if str, ok := f.decodeString(token); ok {
+			f.buf.WriteString(f.encode(str))
+		} else {
+			f.buf.WriteString(f.source(token))
+		}
+
+		f.idx++
+
+	default:
+		f.buf.WriteString(token.String())
+		f.idx++
+	}
+}
+
+func (f *formatter) formatContainer(closeChar string, isObject bool) {
+	openChar := f.current().String()
+	f.idx++
+	if f.isPunct(closeChar) {
+		f.buf.WriteString(openChar + closeChar)
+		f.idx++
+		return
+	}
+
+	f.buf.WriteString(openChar)
+	f.depth++
+	first := true
+	for {
+		// comments before the entry or closing bracket
+		for f.current().Type() == json6.TokenComment {
+			f.newline(!first && f.hasBlankLine())
+			f.buf.WriteString(f.current().String())
+			f.idx++
+			first = false
+		}
+
+		if f.isPunct(closeChar) {
+			f.depth--
+			f.newline(false)
+			f.buf.WriteString(closeChar)
+			f.idx++
+			return
+		}
+
+		f.newline(!first && f.hasBlankLine())
+		first = false
+		if isObject {
+			f.formatKey()
+			f.formatInlineComments()
+			f.idx++ // colon
+			f.buf.WriteByte(':')
+			if !f.formatInlineComments() {
+				f.buf.WriteByte(' ')
+			}
+		}
+
+		// empty element of array is kept as is
+		if !isObject && f.isPunct(",") {
+			f.buf.WriteByte(',')
+			f.idx++
+			f.formatTrailingComments()
+			continue
+		}
+
+		f.formatValue()
+
+		// comments before comma are written after it, followed by comments on the same line as the comma
+		var comments []string
+		for f.current().Type() == json6.TokenComment {
+			comments = append(comments, f.current().String())
+			f.idx++
+		}
+
+		if f.isPunct(",") {
+			f.idx++
+		}
+
+		// comma after the last entry follow the style, whether the source has it or not
+		if !f.isLast(closeChar) || f.TrailingComma {
+			f.buf.WriteByte(',')
+		}
+
+		for f.current().Type() == json6.TokenComment && f.sameLine() {
+			comments = append(comments, f.current().String())
+			f.idx++
+		}
+
+		f.writeComments(comments)
+	}
+}
+
+// isLast check if the next token other than comment is closing bracket closeChar
+func (f *formatter) isLast(closeChar string) bool {
+	for i := f.idx; i < len(f.tokens); i++ {
+		token := f.tokens[i]
+		if token.Type() != json6.TokenComment {
+			return token.Type() == json6.TokenPunctuator && token.String() == closeChar
+		}
+	}
+
+	return false
+}
+
+// writeComments write comments on the current line, comment after line comment is moved to the next line
+// so it is not swallowed by the line comment
+func (f *formatter) writeComments(comments []string) {
+	for i, comment := range comments {
+		if i > 0 && strings.HasPrefix(comments[i-1], "//") {
+			f.newline(false)
+			f.buf.WriteString(comment)
+			continue
+		}
+
+		f.buf.WriteString(" " + comment)
+	}
+}
+
+// formatTrailingComments write comments on the same line as the previous token
+func (f *formatter) formatTrailingComments() {
+	for f.current().Type() == json6.TokenComment && f.sameLine() {
+		f.buf.WriteString(" " + f.current().String())
+		f.idx++
+	}
+}
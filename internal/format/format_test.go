@@ -0,0 +1,196 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src := `// manifest
+{
+  "name": "app", // the name
+  'version':'1.2'  ,
+
+
+  /* deps */
+  deps: [ 'a' /* x */, "it's\n", ,],
+  empty: {}, arr:[],
+  nested: {a: {b: [1, 0x10, -Infinity]}}
+} // end`
+
+	expected := `// manifest
+{
+	name: 'app', // the name
+	version: '1.2',
+
+	/* deps */
+	deps: [
+		'a', /* x */
+		'it\'s\n',
+		,
+	],
+	empty: {},
+	arr: [],
+	nested: {
+		a: {
+			b: [
+				1,
+				0x10,
+				-Infinity,
+			],
+		},
+	},
+} // end
+`
+
+	s := Style{Indent: "\t", Quote: '\'', TrailingComma: true}
+	res, err := Format([]byte(src), s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+		return
+	}
+
+	// formatting formatted document change nothing
+	res, err = Format(res, s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+}
+
+func TestFormatStyle(t *testing.T) {
+	src := `{'a b': 'c', d: [1]}`
+	expected := "{\n  \"a b\": \"c\",\n  d: [\n    1\n  ]\n}\n"
+	res, err := Format([]byte(src), Style{Indent: "  ", Quote: '"'})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+}
+
+func TestFormatTrailingComma(t *testing.T) {
+	src := `{a: 1, b: [1,2,],}`
+	expected := "{\n  a: 1,\n  b: [\n    1,\n    2\n  ]\n}\n"
+	res, err := Format([]byte(src), Style{Indent: "  ", Quote: '\'', TrailingComma: false})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+
+	// comma of empty element is kept, it is not a trailing comma
+	src = `[1,,]`
+	expected = "[\n  1,\n  ,\n]\n"
+	res, err = Format([]byte(src), Style{Indent: "  ", Quote: '\'', TrailingComma: false})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(res), expected)
+	}
+}
+
+func TestFormatCommentsBeforeComma(t *testing.T) {
+	src := "[1 //x\n /*y*/ , 2 /*z*/, 3 //w\n ]"
+	expected := "[\n\t1, //x\n\t/*y*/\n\t2, /*z*/\n\t3, //w\n]\n"
+	s := Style{Indent: "\t", Quote: '\'', TrailingComma: true}
+	res, err := Format([]byte(src), s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+		return
+	}
+
+	// formatting formatted document change nothing
+	res, err = Format(res, s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	if _, err := Format([]byte(`{a: }`), Style{Indent: "\t", Quote: '\''}); err == nil {
+		t.Error("expecting syntax error")
+	}
+}
+
+func TestFormatLoneSurrogate(t *testing.T) {
+	src := `{'\uD83D': "\uDE00 \uD83D\uDE00", b: "\uD83D\uDE00"}`
+	expected := "{\n\t'\\uD83D': \"\\uDE00 \\uD83D\\uDE00\",\n\tb: '\U0001F600',\n}\n"
+	res, err := Format([]byte(src), Style{Indent: "\t", Quote: '\'', TrailingComma: true})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+	}
+}
+
+func TestFormatEscapedIdentifier(t *testing.T) {
+	src := `{\u0041: 1, a\u{62}: 2}`
+	expected := "{\n\t\\u0041: 1,\n\ta\\u{62}: 2,\n}\n"
+	res, err := Format([]byte(src), Style{Indent: "\t", Quote: '\'', TrailingComma: true})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+	}
+}
+
+func TestFormatCommentsAroundColon(t *testing.T) {
+	src := "{a /*k*/ : /*v*/ 1, b //k\n : //v\n 2, c /*k*/ /*l*/: 3}"
+	expected := "{\n\ta /*k*/: /*v*/ 1,\n\tb //k\n\t\t: //v\n\t\t2,\n\tc /*k*/ /*l*/: 3,\n}\n"
+	s := Style{Indent: "\t", Quote: '\'', TrailingComma: true}
+	res, err := Format([]byte(src), s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+		return
+	}
+
+	// formatting formatted document change nothing
+	res, err = Format(res, s)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(res) != expected {
+		t.Errorf("unexpected %q, expecting %q", string(res), expected)
+	}
+}
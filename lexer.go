@@ -60,6 +60,12 @@ func (r *runeReader) addChar(char rune) {
 	r.chars = append(r.chars, char)
 }
 
+// addChars add characters to reader
+func (r *runeReader) addChars(chars []rune) {
+	r.charRng += len(chars)
+	r.chars = append(r.chars, chars...)
+}
+
 // ReadRune read char from reader
 func (r *runeReader) ReadRune() (ch rune, size int, err error) {
 	if r.charIdx+1 <= r.charRng {
@@ -87,6 +93,7 @@ type Token struct {
 	EndPos          *Position
 	t               TokenType
 	tokenNumSubType uint
+	end             int // byte offset right after the last character of the token
 	*runeReader
 }
 
@@ -102,6 +109,12 @@ func (t Token) String() string {
 	return string(t.chars)
 }
 
+// EndOffset return byte offset right after the last character of the token,
+// so src[t.StartPos.Offset():t.EndOffset()] is the source of the token
+func (t Token) EndOffset() int {
+	return t.end
+}
+
 // Type return token type in TokenType
 func (t Token) Type() TokenType {
 	return t.t
@@ -114,8 +127,10 @@ func (t Token) TypeString() string {
 
 // Position indicating token's position
 type Position struct {
-	ln  int
-	col int
+	ln     int
+	col    int
+	col16  int // column in UTF-16 code units
+	offset int // byte offset from the beginning of input
 }
 
 func newPosition(ln, col int) *Position {
@@ -127,11 +142,69 @@ func (pos *Position) Line() int {
 	return pos.ln
 }
 
-// Column of the position of a token
+// Column of the position of a token, counted in Unicode code points
 func (pos *Position) Column() int {
 	return pos.col
 }
 
+// UTF16Column is like Column, but counted in UTF-16 code units, like LSP clients and JavaScript do
+func (pos *Position) UTF16Column() int {
+	return pos.col16
+}
+
+// Offset is byte offset of the character at the position from the beginning of input
+func (pos *Position) Offset() int {
+	return pos.offset
+}
+
+// ColumnEncoding is the unit of column returned by Position.ColumnIn
+type ColumnEncoding uint
+
+// column encodings
+const (
+	ColumnRunes ColumnEncoding = iota // Unicode code points, like Position.Column
+	ColumnUTF16                       // UTF-16 code units, like Position.UTF16Column
+	ColumnBytes                       // UTF-8 bytes
+)
+
+// ColumnIn compute the column of the position in src, the input the position is read from,
+// counted in enc. If tabWidth is greater than zero, tab advance the column to the next multiple of tabWidth,
+// like editors display it, otherwise tab is counted as one character
+func (pos *Position) ColumnIn(src []byte, enc ColumnEncoding, tabWidth int) int {
+	if pos.col == 0 || pos.offset > len(src) {
+		return pos.col
+	}
+
+	// find the beginning of the line
+	lineStart := pos.offset
+	for lineStart > 0 {
+		char, size := utf8.DecodeLastRune(src[:lineStart])
+		if char == '\n' || char == '\r' || char == '\u2028' || char == '\u2029' {
+			break
+		}
+
+		lineStart -= size
+	}
+
+	col := 0
+	for i := lineStart; i < pos.offset; {
+		char, size := utf8.DecodeRune(src[i:pos.offset])
+		i += size
+		switch {
+		case char == '\t' && tabWidth > 0:
+			col += tabWidth - col%tabWidth
+		case enc == ColumnUTF16:
+			col += utf16Len(char)
+		case enc == ColumnBytes:
+			col += size
+		default:
+			col++
+		}
+	}
+
+	return col + 1
+}
+
 func (pos *Position) addLn(add int) {
 	pos.ln += add
 }
@@ -168,31 +241,36 @@ func (tokenR *tokenReader) ReadToken() (Token, error) {
 type Lexer struct {
 	*tokenReader
 	pos       *Position
-	r         io.RuneReader
-	token     Token // current token
-	ignoreErr bool  // set to true to ignore lexical error
+	r         *reader
+	token     Token          // current token
+	ignoreErr bool           // set to true to ignore lexical error
+	diags     []*SyntaxError // lexical errors found while ignoring error
 }
 
 func NewLexer(r io.RuneReader) *Lexer {
 	pos := newPosition(1, 0)
-	r = newReader(r, pos)
 	return &Lexer{
 		tokenReader: newTokenReader(),
 		pos:         pos,
-		r:           r,
+		r:           newReader(r, pos),
 		token:       newToken(),
 	}
 }
 
 func (lx *Lexer) push() {
-	lx.token.EndPos = newPosition(lx.pos.Line(), lx.pos.Column())
+	endPos := *lx.pos
+	lx.token.EndPos = &endPos
+	lx.token.end = lx.r.offset
 	lx.tokens = append(lx.tokens, lx.token)
 	lx.rng += 1
 	lx.token = newToken()
 }
 
-func (lx *Lexer) pushWithPos(ln, cl int) {
-	lx.token.EndPos = newPosition(ln, cl)
+// pushWithPos push the token ended by the character before the last read character
+func (lx *Lexer) pushWithPos() {
+	endPos := lx.r.prev
+	lx.token.EndPos = &endPos
+	lx.token.end = lx.pos.offset
 	lx.tokens = append(lx.tokens, lx.token)
 	lx.rng += 1
 	lx.token = newToken()
@@ -200,156 +278,184 @@ func (lx *Lexer) pushWithPos(ln, cl int) {
 
 // IgnoreError determine if lexer will be ignoring lexical error or not,
 // default behavior is to not allow lexical error.
-// Call IgnoreError(true) to ignore lexical error
+// Call IgnoreError(true) to ignore lexical error, the invalid token is dropped,
+// the lexer resume at the next punctuator or whitespace, and the error is added to Diagnostics
 func (lx *Lexer) IgnoreError(ignore bool) {
 	lx.ignoreErr = ignore
 }
 
-// FetchTokensTokens return fetched tokens
-func (lx *Lexer) FetchTokens() error {
-	for {
-		char, _, err := lx.r.ReadRune()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-
-			return err
-		}
+// Diagnostics return lexical errors ignored so far, in order of their position
+func (lx *Lexer) Diagnostics() []*SyntaxError {
+	return lx.diags
+}
 
-		switch char {
-		// comment
-		case '/':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchComment(); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+// recover record err and resynchronize if lexical error is ignored, otherwise err is returned
+func (lx *Lexer) recover(err error) error {
+	synErr, ok := err.(*SyntaxError)
+	if !lx.ignoreErr || !ok {
+		return err
+	}
 
-				return err
-			}
+	lx.diags = append(lx.diags, synErr)
+	lx.token = newToken()
 
-			continue
+	// skip the rest of invalid token, started from the character that cause the error
+	char := lx.r.lastChar
+	for !lx.r.eof {
+		if isCharPunct(char) {
+			lx.fetchPunct(char)
+			return nil
+		}
 
-		// true boolean
-		case 't':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchTrueBool(); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+		if isCharWhitespace(char) {
+			return nil
+		}
 
-				return err
+		if char, _, err = lx.r.ReadRune(); err != nil {
+			if err == io.EOF {
+				return nil
 			}
 
-			continue
+			return err
+		}
+	}
 
-		// false boolean
-		case 'f':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchFalseBool(); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+	return nil
+}
 
-				return err
-			}
+// Next return the next token, tokens are fetched from the underlying reader on demand
+// and dropped once they are read, so the whole input is never held in memory.
+// Next return io.EOF if there's no more token
+func (lx *Lexer) Next() (Token, error) {
+	for lx.idx >= lx.rng {
+		// every fetched token has been read, reuse the storage
+		lx.tokens = lx.tokens[:0]
+		lx.idx = -1
+		lx.rng = -1
+
+		if err := lx.fetchToken(); err != nil {
+			return Token{}, err
+		}
+	}
 
-			continue
+	return lx.tokenReader.ReadToken()
+}
 
-		// null
-		case 'n':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchNull(); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+// startPos return position of the last read character, the first character of current token
+func (lx *Lexer) startPos() *Position {
+	pos := *lx.pos
+	return &pos
+}
 
-				return err
-			}
+// position return current position of the lexer
+func (lx *Lexer) position() *Position {
+	return lx.pos
+}
 
-			continue
+// ReadToken is like Next, but return ErrNoMoreToken if there's no more token
+func (lx *Lexer) ReadToken() (Token, error) {
+	token, err := lx.Next()
+	if err == io.EOF {
+		return token, ErrNoMoreToken
+	}
 
-		// undefined
-		case 'u':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchUndefined(); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+	return token, err
+}
 
-				return err
+// FetchTokensTokens return fetched tokens
+func (lx *Lexer) FetchTokens() error {
+	for {
+		if err := lx.fetchToken(); err != nil {
+			if err == io.EOF {
+				break
 			}
 
-			continue
+			return err
+		}
+	}
 
-		// punctuator
-		case '{', '}', '[', ']', ':', ',':
-			lx.fetchPunct(char)
-			continue
+	return nil
+}
 
-		// string
-		case '"', '\'', '`':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchString(char); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+// fetchToken read the next character and fetch token started by it,
+// whitespace is skipped and a single call might push more than one token
+// (a number ended by punctuator for example), return io.EOF if there's no more character to read
+func (lx *Lexer) fetchToken() error {
+	char, _, err := lx.r.ReadRune()
+	if err != nil {
+		return err
+	}
 
-				return err
-			}
+	switch char {
+	// comment
+	case '/':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchComment(); err != nil {
+			return lx.recover(err)
+		}
 
-			continue
+	// true boolean
+	case 't':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchTrueBool(); err != nil {
+			return lx.recover(err)
+		}
 
-		// number
-		case '-', '+', '.', 'I', 'N':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchNumber(char); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+	// false boolean
+	case 'f':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchFalseBool(); err != nil {
+			return lx.recover(err)
+		}
 
-				return err
-			}
+	// null
+	case 'n':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchNull(); err != nil {
+			return lx.recover(err)
+		}
 
-			continue
+	// undefined
+	case 'u':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchUndefined(); err != nil {
+			return lx.recover(err)
+		}
 
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			if err := lx.fetchNumber(char); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+	// punctuator
+	case '{', '}', '[', ']', ':', ',':
+		lx.fetchPunct(char)
 
-				return err
-			}
+	// string
+	case '"', '\'', '`':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchString(char); err != nil {
+			return lx.recover(err)
+		}
 
-			continue
+	// number
+	case '-', '+', '.', 'I', 'N':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchNumber(char); err != nil {
+			return lx.recover(err)
+		}
 
-		default:
-			// Check if char is whitespace
-			if isCharWhitespace(char) {
-				continue
-			}
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		lx.token.StartPos = lx.startPos()
+		if err := lx.fetchNumber(char); err != nil {
+			return lx.recover(err)
+		}
 
-			lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
-			// if char is not whitespace, try to fetch identifier token
-			if err := lx.fetchIdentifier(true, char); err != nil {
-				if lx.ignoreErr {
-					lx.token = Token{}
-					continue
-				}
+	default:
+		// Check if char is whitespace
+		if isCharWhitespace(char) {
+			return nil
+		}
 
-				return err
-			}
+		lx.token.StartPos = lx.startPos()
+		// if char is not whitespace, try to fetch identifier token
+		if err := lx.fetchIdentifier(true, char); err != nil {
+			return lx.recover(err)
 		}
 	}
 
@@ -357,6 +463,11 @@ func (lx *Lexer) FetchTokens() error {
 }
 
 func (lx *Lexer) fetchComment() error {
+	// comment can be fetched right after another token without going through fetchToken
+	if lx.token.StartPos == nil {
+		lx.token.StartPos = lx.startPos()
+	}
+
 	lx.token.addChar('/')
 	lx.token.t = TokenComment
 
@@ -384,7 +495,7 @@ func (lx *Lexer) fetchComment() error {
 
 			switch char {
 			case '\r', '\n', '\u2028', '\u2029':
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 
 			default:
@@ -469,14 +580,14 @@ func (lx *Lexer) fetchFalseBool() error {
 		if isCharPunct(char) {
 			defer lx.fetchPunct(char)
 		} else if char == '/' {
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return lx.fetchComment()
 		} else {
 			return lx.fetchIdentifier(false, char)
 		}
 	}
 
-	lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+	lx.pushWithPos()
 	return nil
 }
 
@@ -521,17 +632,17 @@ func (lx *Lexer) fetchTrueBool() error {
 	if !isCharWhitespace(char) {
 		if isCharPunct(char) {
 			defer lx.fetchPunct(char)
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return nil
 		} else if char == '/' {
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return lx.fetchComment()
 		} else {
 			return lx.fetchIdentifier(false, char)
 		}
 	}
 
-	lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+	lx.pushWithPos()
 
 	return nil
 }
@@ -579,17 +690,17 @@ func (lx *Lexer) fetchNull() error {
 	if !isCharWhitespace(char) {
 		if isCharPunct(char) {
 			defer lx.fetchPunct(char)
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return nil
 		} else if char == '/' {
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return lx.fetchComment()
 		} else {
 			return lx.fetchIdentifier(false, char)
 		}
 	}
 
-	lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+	lx.pushWithPos()
 	return nil
 }
 
@@ -610,7 +721,7 @@ func (lx *Lexer) fetchIdentifier(isBegin bool, firstChar rune) error {
 		}
 
 		defer lx.fetchPunct(firstChar)
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return nil
 
 	case '\\':
@@ -647,7 +758,7 @@ func (lx *Lexer) fetchIdentifier(isBegin bool, firstChar rune) error {
 			return errInvalidChar(firstChar, lx.pos, lx.token.chars, "'$', '_', unicode escape sequence, or any charater in categories Uppercase letter (Lu), Lowercase letter (Ll), Titlecase letter (Lt), Modifier letter (Lm), Other letter (Lo), Letter number (Nl)")
 		}
 
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return lx.fetchComment()
 
 	default:
@@ -688,7 +799,7 @@ LOOP:
 		// punctuator
 		case '{', '}', '[', ']', ':', ',':
 			defer lx.fetchPunct(char)
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return nil
 
 		case '\\':
@@ -725,7 +836,7 @@ LOOP:
 			return errInvalidChar(char, lx.pos, lx.token.chars, "'u' or 'x'")
 
 		case '/':
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return lx.fetchComment()
 
 		default:
@@ -742,7 +853,7 @@ LOOP:
 		}
 	}
 
-	lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+	lx.pushWithPos()
 
 	return nil
 }
@@ -777,11 +888,18 @@ func (lx *Lexer) fetchUnicodeEscape() error {
 	// the first char immediately after char 'u'
 	char, _, err := lx.r.ReadRune()
 	if err != nil {
+		if err == io.EOF {
+			return errUnexpectedEOF(lx.pos, "'{' or hexadecimal digit")
+		}
+
 		return err
 	}
 
 	lx.token.addChar(char)
 	if char == '{' {
+		// at least one digit, and the code point must not be greater than 10FFFF
+		digits := 0
+		codePoint := 0
 		for {
 			char, _, err := lx.r.ReadRune()
 			if err != nil {
@@ -794,12 +912,22 @@ func (lx *Lexer) fetchUnicodeEscape() error {
 
 			lx.token.addChar(char)
 			if !isCharValidHexa(char) {
-				if char == '}' {
+				if char == '}' && digits > 0 {
 					return nil
 				}
 
+				if digits == 0 {
+					return errInvalidChar(char, lx.pos, lx.token.chars, "hexadecimal digit")
+				}
+
 				return errInvalidChar(char, lx.pos, lx.token.chars, "hexadecimal digit or '}'")
 			}
+
+			digits++
+			codePoint = codePoint*16 + hexaDigitValue(char)
+			if codePoint > unicode.MaxRune {
+				return errInvalidCodePoint(lx.pos, lx.token.chars)
+			}
 		}
 	}
 
@@ -829,7 +957,7 @@ func (lx *Lexer) fetchUnicodeEscape() error {
 
 // fetchPunct is not exactly for fetching, more like creating the token
 func (lx *Lexer) fetchPunct(char rune) {
-	lx.token.StartPos = newPosition(lx.pos.ln, lx.pos.col)
+	lx.token.StartPos = lx.startPos()
 	lx.token.t = TokenPunctuator
 	lx.token.addChar(char)
 	lx.push()
@@ -877,17 +1005,17 @@ func (lx *Lexer) fetchUndefined() error {
 	if !isCharWhitespace(char) {
 		if isCharPunct(char) {
 			defer lx.fetchPunct(char)
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return nil
 		} else if char == '/' {
-			lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+			lx.pushWithPos()
 			return lx.fetchComment()
 		} else {
 			return lx.fetchIdentifier(false, char)
 		}
 	}
 
-	lx.push()
+	lx.pushWithPos()
 
 	return nil
 }
@@ -989,11 +1117,11 @@ func (lx *Lexer) fetchHexaNumber() error {
 
 			if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '_' {
 				lx.token.addChar(char)
@@ -1014,7 +1142,7 @@ func (lx *Lexer) fetchHexaNumber() error {
 
 				continue
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1069,15 +1197,15 @@ func (lx *Lexer) fetchBinaryNumber() error {
 				continue
 			} else if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 
 				return nil
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1125,13 +1253,13 @@ func (lx *Lexer) fetchInfinityNumber() error {
 
 	if isCharPunct(char) {
 		defer lx.fetchPunct(char)
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return nil
 	} else if isCharWhitespace(char) {
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return nil
 	} else if char == '/' {
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return lx.fetchComment()
 	}
 
@@ -1139,8 +1267,7 @@ func (lx *Lexer) fetchInfinityNumber() error {
 	// might be an identifier, but Infinity can start with '-' and '+' sign, so we must check
 	// if this token start with a sign
 	if char := lx.token.chars[0]; char == '+' || char == '-' {
-		pos := newPosition(lx.pos.ln, lx.pos.col-len(lx.token.chars)-1)
-		return errInvalidChar(char, pos, lx.token.chars, "'$', '_', unicode escape sequence, or any charater in categories Uppercase letter (Lu), Lowercase letter (Ll), Titlecase letter (Lt), Modifier letter (Lm), Other letter (Lo), Letter number (Nl)")
+		return errInvalidChar(char, lx.token.StartPos, lx.token.chars, "'$', '_', unicode escape sequence, or any charater in categories Uppercase letter (Lu), Lowercase letter (Ll), Titlecase letter (Lt), Modifier letter (Lm), Other letter (Lo), Letter number (Nl)")
 	}
 
 	return lx.fetchIdentifier(false, char)
@@ -1181,13 +1308,13 @@ func (lx *Lexer) fetchNanNumber() error {
 
 	if isCharPunct(char) {
 		defer lx.fetchPunct(char)
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return nil
 	} else if isCharWhitespace(char) {
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return nil
 	} else if char == '/' {
-		lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+		lx.pushWithPos()
 		return lx.fetchComment()
 	}
 
@@ -1195,8 +1322,7 @@ func (lx *Lexer) fetchNanNumber() error {
 	// might be an identifier, but NaN can start with '-' and '+' sign, so we must check
 	// if this token start with a sign
 	if char := lx.token.chars[0]; char == '+' || char == '-' {
-		pos := newPosition(lx.pos.ln, lx.pos.col-len(lx.token.chars)-1)
-		return errInvalidChar(char, pos, lx.token.chars, "'$', '_', unicode escape sequence, or any charater in categories Uppercase letter (Lu), Lowercase letter (Ll), Titlecase letter (Lt), Modifier letter (Lm), Other letter (Lo), Letter number (Nl)")
+		return errInvalidChar(char, lx.token.StartPos, lx.token.chars, "'$', '_', unicode escape sequence, or any charater in categories Uppercase letter (Lu), Lowercase letter (Ll), Titlecase letter (Lt), Modifier letter (Lm), Other letter (Lo), Letter number (Nl)")
 	}
 
 	return lx.fetchIdentifier(false, char)
@@ -1228,10 +1354,10 @@ func (lx *Lexer) fetchOctalNumber() error {
 
 			if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '_' {
 				lx.token.addChar(char)
@@ -1251,7 +1377,7 @@ func (lx *Lexer) fetchOctalNumber() error {
 
 				continue
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1316,14 +1442,13 @@ func (lx *Lexer) fetchDoubleNumber() error {
 					return lx.fetchExponentNumber()
 				} else if isCharPunct(char) {
 					defer lx.fetchPunct(char)
-					lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+					lx.pushWithPos()
 					return nil
 				} else if isCharWhitespace(char) {
-					defer lx.fetchPunct(char)
-					lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+					lx.pushWithPos()
 					return nil
 				} else if char == '/' {
-					lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+					lx.pushWithPos()
 					return lx.fetchComment()
 				}
 
@@ -1352,13 +1477,13 @@ func (lx *Lexer) fetchDoubleNumber() error {
 				continue
 			} else if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1440,14 +1565,14 @@ func (lx *Lexer) fetchExponentNumber() error {
 
 				continue
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1573,13 +1698,13 @@ BEGIN_CHAR_CHECK:
 				break
 			} else if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1637,13 +1762,13 @@ BEGIN_CHAR_CHECK:
 
 			if isCharPunct(char) {
 				defer lx.fetchPunct(char)
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if isCharWhitespace(char) {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return nil
 			} else if char == '/' {
-				lx.pushWithPos(lx.pos.ln, lx.pos.col-1)
+				lx.pushWithPos()
 				return lx.fetchComment()
 			}
 
@@ -1671,6 +1796,18 @@ func isCharValidHexa(char rune) bool {
 	return false
 }
 
+// hexaDigitValue return value of hexadecimal digit char
+func hexaDigitValue(char rune) int {
+	switch {
+	case char >= 'a':
+		return int(char-'a') + 10
+	case char >= 'A':
+		return int(char-'A') + 10
+	}
+
+	return int(char - '0')
+}
+
 // isCharValidOctal check if char is valid octal digit
 func isCharValidOctal(char rune) bool {
 	switch char {
@@ -3,6 +3,8 @@ package json6
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -1175,7 +1177,7 @@ func TestTokens(t *testing.T) {
 			"double-quote string",
 			'single-quote string',
 		],
-		"doubleStrIden": "\u1234 \u{12345678} \xFf",
+		"doubleStrIden": "\u1234 \u{10FFFF} \xFf",
 		'singleStrIden': 'Howdy',
 		/*
 			multiline comment
@@ -1236,3 +1238,170 @@ func TestTokens(t *testing.T) {
 		fmt.Print("\n")
 	}
 }
+
+// TestNext test Lexer.Next() behavior
+func TestNext(t *testing.T) {
+	input := `{a: [1, 'two', null], // comment
+		b: true}`
+
+	expects := []string{"{", "a", ":", "[", "1", ",", "'two'", ",", "null", "]", ",", "// comment", "b", ":", "true", "}"}
+
+	lex := NewLexer(bytes.NewReader([]byte(input)))
+	for _, expected := range expects {
+		token, err := lex.Next()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if token.String() != expected {
+			t.Errorf("unexpected %s, expecting %s", token.String(), expected)
+			return
+		}
+
+		// tokens are fetched on demand, so there should never be many tokens stored
+		if len(lex.tokens) > 2 {
+			t.Errorf("unexpected %d stored tokens, expecting at most %d", len(lex.tokens), 2)
+			return
+		}
+	}
+
+	if _, err := lex.Next(); err != io.EOF {
+		t.Errorf("unexpected %v, expecting io.EOF", err)
+	}
+
+	// whitespace after a literal is not part of its span
+	literals := []string{"undefined", "null", "true", "false", "Infinity", "-Infinity", "NaN", "0x1F", "0b1", "0o7", "07", "1.5", "1e3", "10", "'x'", "ident"}
+	lex = NewLexer(strings.NewReader(strings.Join(literals, "\n ") + "\n"))
+	for _, expected := range literals {
+		token, err := lex.Next()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		start, end := token.StartPos, token.EndPos
+		if token.String() != expected || end.Line() != start.Line() || end.Column() != start.Column()+len(expected)-1 ||
+			token.EndOffset() != start.Offset()+len(expected) {
+			t.Errorf("unexpected %q from %d:%d to %d:%d, expecting %q", token.String(), start.Line(), start.Column(), end.Line(), end.Column(), expected)
+		}
+	}
+}
+
+func TestIgnoreErrorDiagnostics(t *testing.T) {
+	input := `{a: #bad, b: 0o19 ,
+	c: 1e+}`
+
+	expects := []string{"{", "a", ":", ",", "b", ":", ",", "c", ":", "}"}
+
+	lex := NewLexer(bytes.NewReader([]byte(input)))
+	lex.IgnoreError(true)
+	for _, expected := range expects {
+		token, err := lex.Next()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if token.String() != expected {
+			t.Errorf("unexpected %s, expecting %s", token.String(), expected)
+			return
+		}
+	}
+
+	if _, err := lex.Next(); err != io.EOF {
+		t.Errorf("unexpected %v, expecting io.EOF", err)
+	}
+
+	diags := lex.Diagnostics()
+	expectPos := [][2]int{{1, 5}, {1, 17}, {2, 8}}
+	if len(diags) != len(expectPos) {
+		t.Errorf("unexpected %d diagnostics, expecting %d", len(diags), len(expectPos))
+		return
+	}
+
+	for i, diag := range diags {
+		if diag.Pos.Line() != expectPos[i][0] || diag.Pos.Column() != expectPos[i][1] {
+			t.Errorf("unexpected position %d:%d of %q, expecting %d:%d",
+				diag.Pos.Line(), diag.Pos.Column(), diag.Error(), expectPos[i][0], expectPos[i][1])
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "{\r\n\tkey: '😀é', // c\r\n\tn: 10\n}"
+	type span struct {
+		str                  string
+		ln, col, col16       int
+		start, end           int
+		endLn, endCol, end16 int
+	}
+
+	expects := []span{
+		{"{", 1, 1, 1, 0, 1, 1, 1, 1},
+		{"key", 2, 2, 2, 4, 7, 2, 4, 4},
+		{":", 2, 5, 5, 7, 8, 2, 5, 5},
+		{"'😀é'", 2, 7, 7, 9, 17, 2, 10, 11},
+		{",", 2, 11, 12, 17, 18, 2, 11, 12},
+		{"// c", 2, 13, 14, 19, 23, 2, 16, 17},
+		{"n", 3, 2, 2, 26, 27, 3, 2, 2},
+		{":", 3, 3, 3, 27, 28, 3, 3, 3},
+		{"10", 3, 5, 5, 29, 31, 3, 6, 6},
+		{"}", 4, 1, 1, 32, 33, 4, 1, 1},
+	}
+
+	lex := NewLexer(bytes.NewReader([]byte(input)))
+	for _, expected := range expects {
+		token, err := lex.Next()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		got := span{
+			token.String(),
+			token.StartPos.Line(), token.StartPos.Column(), token.StartPos.UTF16Column(),
+			token.StartPos.Offset(), token.EndOffset(),
+			token.EndPos.Line(), token.EndPos.Column(), token.EndPos.UTF16Column(),
+		}
+
+		if got != expected {
+			t.Errorf("unexpected %v, expecting %v", got, expected)
+		}
+
+		if input[token.StartPos.Offset():token.EndOffset()] != token.String() {
+			t.Errorf("unexpected source %q of token %q", input[token.StartPos.Offset():token.EndOffset()], token.String())
+		}
+	}
+}
+
+func TestPositionColumnIn(t *testing.T) {
+	input := []byte("[\n\t'😀é',\tx]")
+	lex := NewLexer(bytes.NewReader(input))
+	var pos *Position
+	for {
+		token, err := lex.Next()
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if token.String() == "x" {
+			pos = token.StartPos
+			break
+		}
+	}
+
+	encodings := []ColumnEncoding{ColumnRunes, ColumnUTF16, ColumnBytes, ColumnRunes, ColumnUTF16}
+	tabWidths := []int{0, 0, 0, 4, 8}
+	expects := []int{8, 9, 12, 13, 17}
+	for i, enc := range encodings {
+		if col := pos.ColumnIn(input, enc, tabWidths[i]); col != expects[i] {
+			t.Errorf("unexpected column %d with encoding %d and tab width %d, expecting %d", col, enc, tabWidths[i], expects[i])
+		}
+	}
+
+	if pos.Column() != expects[0] || pos.UTF16Column() != expects[1] {
+		t.Errorf("unexpected column %d and UTF-16 column %d", pos.Column(), pos.UTF16Column())
+	}
+}
@@ -0,0 +1,215 @@
+package json6
+
+import (
+	"math/big"
+	"reflect"
+)
+
+// Kind is type of JSON6 value held by a Node
+type Kind uint
+
+// kinds of Node
+const (
+	KindNull Kind = iota
+	KindUndefined
+	KindBool
+	KindInteger
+	KindDouble
+	KindString
+	KindObject
+	KindArray
+	KindHole // empty array element, like the second element of [1,,3]
+)
+
+var kindStrings = map[Kind]string{
+	KindNull:      "null",
+	KindUndefined: "undefined",
+	KindBool:      "boolean",
+	KindInteger:   "integer",
+	KindDouble:    "double",
+	KindString:    "string",
+	KindObject:    "object",
+	KindArray:     "array",
+	KindHole:      "hole",
+}
+
+func (k Kind) String() string {
+	return kindStrings[k]
+}
+
+// Node is a JSON6 value as document tree, members of object keep their order in source.
+// Node and *Node can be the target of Unmarshal and Decoder.Decode, and can be encoded by Marshal
+// and Encoder.Encode, so documents can be inspected, edited, and written back without reordering keys
+type Node struct {
+	Kind     Kind
+	Pos      Position   // position of the value, zero if the node is not decoded from source
+	Raw      string     // source text of the value including comments inside it, empty if the node is not decoded from source
+	Bool     bool       // if Kind == KindBool
+	Int      int64      // if Kind == KindInteger
+	BigInt   *big.Int   // if Kind == KindInteger and the integer is out of int64 range, Int is zero
+	Float    float64    // if Kind == KindDouble, infinity if the double is out of float64 range
+	BigFloat *big.Float // if Kind == KindDouble and the double is out of float64 range, nil otherwise
+	Str      string     // if Kind == KindString
+	Members  []Member   // if Kind == KindObject
+	Elems    []*Node    // if Kind == KindArray
+}
+
+// Member is a member of JSON6 object
+type Member struct {
+	Key    string
+	KeyPos Position // position of the key, zero if the member is not decoded from source
+	Value  *Node
+}
+
+var nodeType = reflect.TypeOf(Node{})
+
+// valueKinds map type of decoded value to Kind
+var valueKinds = map[valueType]Kind{
+	valueNull:      KindNull,
+	valueUndefined: KindUndefined,
+	valueBoolean:   KindBool,
+	valueInteger:   KindInteger,
+	valueDouble:    KindDouble,
+	valueString:    KindString,
+	valueObject:    KindObject,
+	valueArray:     KindArray,
+	valueHole:      KindHole,
+}
+
+// newNode convert val into Node
+func (dec *decoder) newNode(val *value) *Node {
+	node := new(Node)
+	if val.pos != nil {
+		node.Pos = *val.pos
+	}
+
+	node.Raw = string(dec.raw(val))
+
+	node.Kind = valueKinds[val.t]
+	switch val.t {
+	case valueBoolean:
+		node.Bool = val.boolVal
+
+	case valueInteger:
+		node.Int = val.intVal
+		node.BigInt = val.bigVal
+
+	case valueDouble:
+		node.Float = val.floatVal
+		if val.overflow {
+			// keep the value of the literal, so it is not encoded back as infinity
+			node.BigFloat = new(big.Float)
+			assignBigFloat(node.BigFloat, val)
+		}
+
+	case valueString:
+		node.Str = val.strVal
+
+	case valueObject:
+		node.Members = make([]Member, 0, len(val.keys))
+		for _, k := range val.keys {
+			v := val.objVal[k]
+			member := Member{Key: k, Value: dec.newNode(&v)}
+			if pos := val.keyPos[k]; pos != nil {
+				member.KeyPos = *pos
+			}
+
+			node.Members = append(node.Members, member)
+		}
+
+	case valueArray:
+		node.Elems = make([]*Node, 0, len(val.arrVal))
+		for i := range val.arrVal {
+			node.Elems = append(node.Elems, dec.newNode(&val.arrVal[i]))
+		}
+	}
+
+	return node
+}
+
+// Get get value of object member by key, nil if the node is not an object or the key is not found
+func (n *Node) Get(key string) *Node {
+	for _, member := range n.Members {
+		if member.Key == key {
+			return member.Value
+		}
+	}
+
+	return nil
+}
+
+// Set replace value of object member by key, the member is appended if the key is not found
+func (n *Node) Set(key string, val *Node) {
+	for i, member := range n.Members {
+		if member.Key == key {
+			n.Members[i].Value = val
+			return
+		}
+	}
+
+	n.Members = append(n.Members, Member{Key: key, Value: val})
+}
+
+// Delete remove object member by key, returns false if the key is not found
+func (n *Node) Delete(key string) bool {
+	for i, member := range n.Members {
+		if member.Key == key {
+			n.Members = append(n.Members[:i], n.Members[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Interface convert the node into Go value, like decoding to interface{}.
+// Object is converted into map[string]interface{}, array into []interface{},
+// integer into int64, or *big.Int if it is out of int64 range, double into float64,
+// undefined into Undefined, empty array element into Hole, and null and nil node into nil
+func (n *Node) Interface() interface{} {
+	if n == nil {
+		return nil
+	}
+
+	switch n.Kind {
+	case KindUndefined:
+		return Undefined
+
+	case KindHole:
+		return Hole
+
+	case KindBool:
+		return n.Bool
+
+	case KindInteger:
+		if n.BigInt != nil {
+			return n.BigInt
+		}
+
+		return n.Int
+
+	case KindDouble:
+		return n.Float
+
+	case KindString:
+		return n.Str
+
+	case KindObject:
+		m := make(map[string]interface{})
+		for _, member := range n.Members {
+			m[member.Key] = member.Value.Interface()
+		}
+
+		return m
+
+	case KindArray:
+		arr := make([]interface{}, 0)
+		for _, elem := range n.Elems {
+			arr = append(arr, elem.Interface())
+		}
+
+		return arr
+	}
+
+	return nil
+}
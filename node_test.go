@@ -0,0 +1,125 @@
+package json6
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalNode(t *testing.T) {
+	src := `{
+	// keys are not sorted
+	zeta: 1,
+	alpha: [true, 0x10, 1.5, undefined],
+	mid: {b: 'b', a: null},
+}`
+
+	var node Node
+	if err := Unmarshal([]byte(src), &node); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if node.Kind != KindObject {
+		t.Errorf("unexpected kind %s, expecting %s", node.Kind, KindObject)
+		return
+	}
+
+	var keys []string
+	for _, member := range node.Members {
+		keys = append(keys, member.Key)
+	}
+
+	if !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	alpha := node.Get("alpha")
+	if alpha == nil || len(alpha.Elems) != 4 {
+		t.Errorf("unexpected %#v", alpha)
+		return
+	}
+
+	if alpha.Elems[1].Kind != KindInteger || alpha.Elems[1].Int != 16 || alpha.Elems[1].Raw != "0x10" {
+		t.Errorf("unexpected %#v", alpha.Elems[1])
+	}
+
+	if alpha.Elems[3].Kind != KindUndefined {
+		t.Errorf("unexpected kind %s, expecting %s", alpha.Elems[3].Kind, KindUndefined)
+	}
+
+	if node.Members[1].KeyPos.Line() != 4 || alpha.Pos.Line() != 4 || alpha.Pos.Column() != 9 {
+		t.Errorf("unexpected key position %d:%d and value position %d:%d",
+			node.Members[1].KeyPos.Line(), node.Members[1].KeyPos.Column(), alpha.Pos.Line(), alpha.Pos.Column())
+	}
+
+	// raw is sliced from the source, comments and whitespace are kept
+	if node.Raw != src {
+		t.Errorf("unexpected raw %q, expecting %q", node.Raw, src)
+	}
+
+	if raw := node.Get("mid").Raw; raw != "{b: 'b', a: null}" {
+		t.Errorf("unexpected raw %q, expecting %q", raw, "{b: 'b', a: null}")
+	}
+
+	expected := map[string]interface{}{"b": "b", "a": nil}
+	if !reflect.DeepEqual(node.Get("mid").Interface(), expected) {
+		t.Errorf("unexpected %#v, expecting %#v", node.Get("mid").Interface(), expected)
+	}
+}
+
+func TestMarshalNode(t *testing.T) {
+	var doc struct {
+		Name string `json6:"name"`
+		Meta *Node  `json6:"meta"`
+	}
+
+	if err := Unmarshal([]byte(`{name: 'app', meta: {version: '1.2', build: 3, tags: ['a']}}`), &doc); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	doc.Meta.Set("version", &Node{Kind: KindString, Str: "1.3"})
+	doc.Meta.Set("stable", &Node{Kind: KindBool, Bool: true})
+	if !doc.Meta.Delete("build") {
+		t.Error("expecting build is deleted")
+	}
+
+	expected := `{name:"app",meta:{version:"1.3",tags:["a"],stable:true}}`
+	byts, err := Marshal(doc)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
+
+func TestMarshalNodeDoubleOutOfRange(t *testing.T) {
+	var node Node
+	if err := Unmarshal([]byte(`{a: 1e400, b: -1.5e400, c: Infinity}`), &node); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if a := node.Get("a"); a.BigFloat == nil || !math.IsInf(a.Float, 1) {
+		t.Errorf("unexpected %#v, expecting BigFloat and infinite Float", a)
+	}
+
+	if c := node.Get("c"); c.BigFloat != nil {
+		t.Errorf("unexpected BigFloat %s of Infinity, expecting nil", c.BigFloat)
+	}
+
+	expected := `{a:1e+400,b:-1.5e+400,c:Infinity}`
+	byts, err := Marshal(node)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if string(byts) != expected {
+		t.Errorf("unexpected %s, expecting %s", string(byts), expected)
+	}
+}
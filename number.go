@@ -0,0 +1,165 @@
+package json6
+
+import (
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Number is a JSON6 number literal, decoded into interface{} when Decoder.UseNumber is called.
+// Integers are written in decimal and digit separators are removed,
+// so the literal keeps the full precision of the source, like "-9223372036854775809", "1.5e300" or "Infinity"
+type Number string
+
+// String returns the literal text of the number
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as float64
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as int64
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+var (
+	numberType   = reflect.TypeOf(Number(""))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// isNumberValue check if val is integer or double
+func isNumberValue(val *value) bool {
+	return val.t == valueInteger || val.t == valueDouble
+}
+
+// numberLiteral return number val as Number
+func numberLiteral(val *value) Number {
+	if val.t == valueInteger {
+		if val.bigVal != nil {
+			return Number(val.bigVal.String())
+		}
+
+		return Number(strconv.FormatInt(val.intVal, 10))
+	}
+
+	str, isMinus := splitNumberSign(val.rnReader.chars)
+	str = strings.ReplaceAll(str, "_", "")
+	if isMinus {
+		str = "-" + str
+	}
+
+	return Number(str)
+}
+
+// isValidNumber check if n is a single JSON6 number literal
+func isValidNumber(n Number) bool {
+	lx := NewLexer(strings.NewReader(string(n)))
+	token, err := lx.Next()
+	if err != nil || token.t != TokenNumber {
+		return false
+	}
+
+	_, err = lx.Next()
+	return err == io.EOF
+}
+
+// assignBigIntValue assign integer out of int64 range to refVal
+func assignBigIntValue(refVal reflect.Value, val *value) error {
+	switch refVal.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !val.bigVal.IsUint64() || refVal.OverflowUint(val.bigVal.Uint64()) {
+			return errMismatchType(val, refVal.Type())
+		}
+
+		refVal.SetUint(val.bigVal.Uint64())
+
+	case reflect.Float32, reflect.Float64:
+		f, _ := new(big.Float).SetInt(val.bigVal).Float64()
+		refVal.SetFloat(f)
+
+	case reflect.Interface:
+		refVal.Set(reflect.ValueOf(new(big.Int).Set(val.bigVal)))
+
+	default:
+		return errMismatchType(val, refVal.Type())
+	}
+
+	return nil
+}
+
+// assignBigInt assign number val to b, double is accepted if it has no fraction, like 1e30
+func assignBigInt(b *big.Int, val *value) error {
+	if val.t == valueInteger {
+		if val.bigVal != nil {
+			b.Set(val.bigVal)
+		} else {
+			b.SetInt64(val.intVal)
+		}
+
+		return nil
+	}
+
+	// parse the literal, float64 can not hold every integer of large double
+	r, ok := new(big.Rat).SetString(string(numberLiteral(val)))
+	if !ok || !r.IsInt() {
+		return errMismatchType(val, reflect.TypeOf(b))
+	}
+
+	b.Set(r.Num())
+	return nil
+}
+
+// assignBigFloat assign number val to f, with precision of f or 64 if it is zero.
+// NaN can not be represented by big.Float
+func assignBigFloat(f *big.Float, val *value) error {
+	switch {
+	case val.t == valueInteger && val.bigVal != nil:
+		f.SetInt(val.bigVal)
+
+	case val.t == valueInteger:
+		f.SetInt64(val.intVal)
+
+	case math.IsNaN(val.floatVal):
+		return errMismatchType(val, reflect.TypeOf(f))
+
+	case math.IsInf(val.floatVal, 0) && !val.overflow:
+		f.SetInf(val.floatVal < 0)
+
+	default:
+		// parse the literal, so the precision is not limited to float64
+		if _, ok := f.SetString(string(numberLiteral(val))); !ok {
+			return errMismatchType(val, reflect.TypeOf(f))
+		}
+	}
+
+	return nil
+}
+
+// writeBigFloat write f in the shortest form that decode back to the same value
+func (e *encodeState) writeBigFloat(f *big.Float) {
+	if f.IsInf() {
+		if f.Signbit() {
+			e.WriteString("-Infinity")
+		} else {
+			e.WriteString("Infinity")
+		}
+
+		return
+	}
+
+	str := f.Text('g', -1)
+	// make sure float is not decoded back as integer
+	if !strings.ContainsAny(str, ".eE") {
+		str += ".0"
+	}
+
+	e.WriteString(str)
+}
@@ -0,0 +1,111 @@
+package json6
+
+import "reflect"
+
+// UndefinedType is the type of Undefined
+type UndefinedType struct{}
+
+// Undefined is stored in interface{} target when the decoded value is undefined,
+// so it can be told apart from null, which is stored as nil.
+// Undefined is encoded as undefined
+var Undefined = UndefinedType{}
+
+// HoleType is the type of Hole
+type HoleType struct{}
+
+// Hole is stored in interface{} element when the decoded array element is empty, like the second element of [1,,3].
+// Hole is encoded as empty element in arrays and as undefined elsewhere
+var Hole = HoleType{}
+
+// FieldState is presence state of a Field
+type FieldState uint
+
+// field states
+const (
+	FieldMissing   FieldState = iota // the key is absent, this is the zero value
+	FieldNull                        // the value is null
+	FieldUndefined                   // the value is undefined
+	FieldSet                         // the value is neither null nor undefined
+)
+
+var fieldStateStrings = map[FieldState]string{
+	FieldMissing:   "missing",
+	FieldNull:      "null",
+	FieldUndefined: "undefined",
+	FieldSet:       "set",
+}
+
+func (s FieldState) String() string {
+	return fieldStateStrings[s]
+}
+
+// Field is a struct field that record whether its key is absent, null, undefined, or set,
+// for example to tell "inherit" from "explicitly unset" in config overlays.
+// Value is decoded like interface{}, set it to a pointer before decoding to decode into a typed value.
+// Value is only changed when the state is FieldSet
+//
+//	overlay := struct {
+//		Timeout json6.Field
+//	}{Timeout: json6.Field{Value: new(int)}}
+//
+// Field must not be a pointer, decoding null into *Field set the pointer to nil.
+// When encoding, Field with FieldMissing state is omitted, FieldNull and FieldUndefined
+// are written as null and undefined, and FieldSet is written as Value
+type Field struct {
+	State FieldState
+	Value interface{}
+}
+
+var (
+	undefinedType = reflect.TypeOf(Undefined)
+	holeType      = reflect.TypeOf(Hole)
+	fieldType     = reflect.TypeOf(Field{})
+)
+
+// isHole check if v is Hole, or interface holding Hole
+func isHole(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v.IsValid() && v.Type() == holeType
+}
+
+// isMissingField check if v is Field with FieldMissing state
+func isMissingField(v reflect.Value) bool {
+	return v.Type() == fieldType && FieldState(v.Field(0).Uint()) == FieldMissing
+}
+
+// assignPresenceField assign val to Field refVal
+func (dec *decoder) assignPresenceField(refVal reflect.Value, val *value) error {
+	switch val.t {
+	case valueNull:
+		refVal.Field(0).SetUint(uint64(FieldNull))
+
+	case valueUndefined, valueHole:
+		refVal.Field(0).SetUint(uint64(FieldUndefined))
+
+	default:
+		refVal.Field(0).SetUint(uint64(FieldSet))
+		return dec.assignValue(refVal.Field(1), val)
+	}
+
+	return nil
+}
+
+// encodePresenceField encode Field v, Field with FieldMissing state is omitted by encodeStruct
+func (e *encodeState) encodePresenceField(v reflect.Value, depth int) error {
+	f := v.Interface().(Field)
+	switch f.State {
+	case FieldSet:
+		return e.encode(reflect.ValueOf(f.Value), depth)
+
+	case FieldUndefined:
+		e.WriteString("undefined")
+
+	default:
+		e.WriteString("null")
+	}
+
+	return nil
+}
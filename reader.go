@@ -1,38 +1,150 @@
 package json6
 
-import "io"
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
 
 type reader struct {
 	p        *Position
+	prev     Position // position before the last read character, the character before it
 	r        io.RuneReader
 	lastChar rune
+	lastSize int  // size of the last read character in bytes
+	offset   int  // count of bytes read
+	units    int  // count of UTF-16 code units read in the current line
+	eof      bool // set to true when the underlying reader return io.EOF
 }
 
 func newReader(r io.RuneReader, pos *Position) *reader {
 	return &reader{p: pos, r: r}
 }
 
+// ReadRune read a character and move the position to it,
+// "\r\n" is counted as one line terminator
 func (r *reader) ReadRune() (rune, int, error) {
 	char, size, err := r.r.ReadRune()
 	if err != nil {
+		if err == io.EOF {
+			r.eof = true
+		}
+
 		return 0, 0, err
 	}
 
+	prevChar := r.lastChar
+	r.prev = *r.p
 	r.lastChar = char
-	if char == '\n' {
-		if r.lastChar != '\r' {
-			r.p.addLn(1)
-			r.p.setCol(0)
-		}
-	} else if char == '\r' {
-		r.p.addLn(1)
-		r.p.setCol(0)
-	} else if char == '\u2028' || char == '\u2029' {
+	r.lastSize = size
+	r.p.offset = r.offset
+	r.offset += size
+	switch {
+	case char == '\n' && prevChar == '\r':
+		// the line is already counted by '\r'
+
+	case char == '\n', char == '\r', char == '\u2028', char == '\u2029':
 		r.p.addLn(1)
 		r.p.setCol(0)
-	} else {
+		r.p.col16 = 0
+		r.units = 0
+
+	default:
 		r.p.addCol(1)
+		r.p.col16 = r.units + 1
+		r.units += utf16Len(char)
+	}
+
+	return char, size, nil
+}
+
+// utf16Len return count of UTF-16 code units needed to encode char
+func utf16Len(char rune) int {
+	if char >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
+
+// countLines return count of line terminators in src, counted like ReadRune,
+// "\r\n" is one line terminator
+func countLines(src []byte) int {
+	count := 0
+	prevChar := rune(0)
+	for len(src) > 0 {
+		char, size := utf8.DecodeRune(src)
+		src = src[size:]
+		switch {
+		case char == '\n' && prevChar == '\r':
+			// already counted by '\r'
+
+		case char == '\n', char == '\r', char == '\u2028', char == '\u2029':
+			count++
+		}
+
+		prevChar = char
+	}
+
+	return count
+}
+
+// sourceBuffer keep bytes of the input from byte offset base,
+// so source of a value can be sliced by its offsets without copying it while decoding
+type sourceBuffer struct {
+	buf     []byte
+	base    int  // byte offset of buf[0] in the input
+	capture bool // set to true to keep every byte written, otherwise only the last written rune is kept
+}
+
+// Write append p, sourceBuffer is written by sourceReader as the input is read.
+// If capture is false, p replace the buffer, so the rune read ahead of a value is still kept
+// when capture start at the next value
+func (s *sourceBuffer) Write(p []byte) (int, error) {
+	if !s.capture {
+		s.base += len(s.buf)
+		s.buf = s.buf[:0]
+	}
+
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+// discard drop bytes before byte offset, they are never sliced again
+func (s *sourceBuffer) discard(offset int) {
+	if n := offset - s.base; n > 0 && n <= len(s.buf) {
+		s.buf = append(s.buf[:0], s.buf[n:]...)
+		s.base = offset
+	}
+}
+
+// slice return bytes between byte offsets start and end, nil if they are discarded or not read yet
+func (s *sourceBuffer) slice(start, end int) []byte {
+	start, end = start-s.base, end-s.base
+	if start < 0 || end > len(s.buf) || start > end {
+		return nil
+	}
+
+	return s.buf[start:end]
+}
+
+// sourceReader is io.RuneReader that write bytes of each read rune to src,
+// bytes are written as the lexer read them, not as they are buffered from the input
+type sourceReader struct {
+	r   *bufio.Reader
+	src *sourceBuffer
+}
+
+func (s *sourceReader) ReadRune() (rune, int, error) {
+	// peek instead of ReadRune, so invalid UTF-8 byte is written as is instead of U+FFFD
+	byts, err := s.r.Peek(utf8.UTFMax)
+	if len(byts) == 0 {
+		return 0, 0, err
 	}
 
+	char, size := utf8.DecodeRune(byts)
+	s.src.Write(byts[:size])
+	s.r.Discard(size)
+
 	return char, size, nil
 }
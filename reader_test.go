@@ -23,3 +23,16 @@ func TestReader(t *testing.T) {
 		t.Errorf("unexpected %d lines, expecting %d", r.p.ln, 5)
 	}
 }
+
+func TestReaderCRLF(t *testing.T) {
+	r := newReader(bytes.NewReader([]byte("a\r\nb\rc\n\rd")), newPosition(1, 0))
+	for {
+		if _, _, err := r.ReadRune(); err != nil {
+			break
+		}
+	}
+
+	if r.p.ln != 5 || r.p.col != 1 || r.p.offset != 8 {
+		t.Errorf("unexpected %d:%d at offset %d, expecting 5:1 at offset 8", r.p.ln, r.p.col, r.p.offset)
+	}
+}
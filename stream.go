@@ -0,0 +1,161 @@
+package json6
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Decoder reads and decodes successive JSON6 values from an input stream
+type Decoder struct {
+	d *decoder
+}
+
+// NewDecoder initiate new Decoder that reads from r
+func NewDecoder(r io.Reader) *Decoder {
+	// source of the value being decoded is kept for Unmarshaler and Node.Raw,
+	// only if the destination may need it
+	src := new(sourceBuffer)
+	return &Decoder{d: &decoder{lx: NewLexer(&sourceReader{r: bufio.NewReader(r), src: src}), src: src}}
+}
+
+// CaseSensitive determine if object key must exactly match struct field name or tag.
+// By default a key that match no field exactly is matched case insensitively, like encoding/json.
+// Call CaseSensitive(true) to require exact match
+func (dec *Decoder) CaseSensitive(caseSensitive bool) {
+	dec.d.caseSensitive = caseSensitive
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when an object key
+// does not match any exported struct field of the destination
+func (dec *Decoder) DisallowUnknownFields() {
+	dec.d.disallowUnknownFields = true
+}
+
+// DuplicateKeys set how a repeated key in an object is handled,
+// default is DuplicateKeysLastWins
+func (dec *Decoder) DuplicateKeys(policy DuplicateKeyPolicy) {
+	dec.d.duplicateKeys = policy
+}
+
+// ArrayHoles set how an empty array element, like the second element of [1,,3], is handled,
+// default is ArrayHolesKeep
+func (dec *Decoder) ArrayHoles(policy ArrayHolePolicy) {
+	dec.d.arrayHoles = policy
+}
+
+// LoneSurrogates set how an escaped UTF-16 surrogate without its pair, like "\uD83D", is handled,
+// default is LoneSurrogatesReplace
+func (dec *Decoder) LoneSurrogates(policy LoneSurrogatePolicy) {
+	dec.d.loneSurrogates = policy
+}
+
+// UseNumber causes the Decoder to decode numbers into interface{} as Number instead of int64 or float64,
+// so they keep the precision of the source
+func (dec *Decoder) UseNumber() {
+	dec.d.useNumber = true
+}
+
+// Decode reads the next JSON6 value from its input and stores it in the value pointed to by v,
+// values can be concatenated or separated by whitespace or comment.
+// Decode returns io.EOF if there's no more value to read
+func (dec *Decoder) Decode(v interface{}) error {
+	refVal, err := valToReflect(v)
+	if err != nil {
+		return err
+	}
+
+	dec.d.src.capture = needsRaw(refVal, make(map[uintptr]bool))
+	for {
+		token, err := dec.d.lx.Next()
+		if err != nil {
+			return err
+		}
+
+		if token.t == TokenComment {
+			dec.d.src.discard(token.EndOffset())
+			continue
+		}
+
+		// source before the value is never needed again
+		dec.d.src.discard(token.StartPos.Offset())
+		val, err := dec.d.decodeTokenValue(token)
+		if err != nil {
+			return err
+		}
+
+		return dec.d.assignValue(refVal, &val)
+	}
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	rawTypeCache    sync.Map // map[reflect.Type]bool, result of typeNeedsRaw
+)
+
+// needsRaw check if decoding into v may need source of the value, that is if v may hold Unmarshaler or Node.
+// Interfaces and pointers already set are followed, visited is set of followed pointers
+func needsRaw(v reflect.Value, visited map[uintptr]bool) bool {
+	if typeNeedsRaw(v.Type()) {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return false
+		}
+
+		visited[v.Pointer()] = true
+		return needsRaw(v.Elem(), visited)
+
+	case reflect.Interface:
+		return !v.IsNil() && needsRaw(v.Elem(), visited)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if needsRaw(v.Field(i), visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// typeNeedsRaw check if value of type t may need source of the value, interface is checked by needsRaw
+func typeNeedsRaw(t reflect.Type) bool {
+	if needs, ok := rawTypeCache.Load(t); ok {
+		return needs.(bool)
+	}
+
+	needs := typeNeedsRawVisit(t, make(map[reflect.Type]bool))
+	rawTypeCache.Store(t, needs)
+	return needs
+}
+
+func typeNeedsRawVisit(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+
+	visited[t] = true
+	if t == nodeType || t.Implements(unmarshalerType) || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return typeNeedsRawVisit(t.Elem(), visited)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if typeNeedsRawVisit(t.Field(i).Type, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
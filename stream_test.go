@@ -0,0 +1,364 @@
+package json6
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDecoderDecode(t *testing.T) {
+	input := `
+	// first value
+	{name: 'first', age: 1}
+	{name: "second", age: 2}{name: ` + "`third`" + `, age: 3}
+	/* end of stream */
+	`
+
+	expects := []string{"first", "second", "third"}
+	dec := NewDecoder(strings.NewReader(input))
+	for i, expected := range expects {
+		var val Profile
+		if err := dec.Decode(&val); err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if val.Name != expected || val.Age != i+1 {
+			t.Errorf("unexpected %#v, expecting name %s and age %d", val, expected, i+1)
+			return
+		}
+	}
+
+	var val Profile
+	if err := dec.Decode(&val); err != io.EOF {
+		t.Errorf("unexpected %v, expecting io.EOF", err)
+	}
+}
+
+func TestDecoderDecodeScalars(t *testing.T) {
+	input := "1 -2\n0x3 [4] 'five'"
+	dec := NewDecoder(strings.NewReader(input))
+	var vals []interface{}
+	for {
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			t.Error(err.Error())
+			return
+		}
+
+		vals = append(vals, val)
+	}
+
+	if len(vals) != 5 {
+		t.Errorf("unexpected %d values, expecting %d", len(vals), 5)
+		return
+	}
+
+	if vals[2] != int64(3) {
+		t.Errorf("unexpected %#v, expecting %#v", vals[2], int64(3))
+	}
+
+	if vals[4] != "five" {
+		t.Errorf("unexpected %#v, expecting %#v", vals[4], "five")
+	}
+}
+
+func TestDecoderDecodeIncompleteValue(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("{a: 1} {b: 2"))
+	val := make(map[string]interface{})
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := dec.Decode(&val); err == nil || err == io.EOF {
+		t.Errorf("unexpected %v, expecting unexpected end of token stream error", err)
+	}
+}
+
+type timeoutConfig struct {
+	Timeout int
+	Retry   int `json6:"retry"`
+}
+
+func TestDecoderCaseSensitive(t *testing.T) {
+	input := `{timeout: 5, RETRY: 3} {Timeout: 1, TIMEOUT: 2}`
+	dec := NewDecoder(strings.NewReader(input))
+	var val timeoutConfig
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Timeout != 5 || val.Retry != 3 {
+		t.Errorf("unexpected %#v, expecting timeout 5 and retry 3", val)
+	}
+
+	// the last key in source that match the field wins, like encoding/json
+	val = timeoutConfig{}
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Timeout != 2 {
+		t.Errorf("unexpected timeout %d, expecting 2", val.Timeout)
+	}
+
+	dec = NewDecoder(strings.NewReader(input))
+	dec.CaseSensitive(true)
+	val = timeoutConfig{}
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if val.Timeout != 0 || val.Retry != 0 {
+		t.Errorf("unexpected %#v, expecting zero value", val)
+	}
+}
+
+func TestDecoderDisallowUnknownFields(t *testing.T) {
+	input := `{
+	name: 'app',
+	servers: [{host: 'a', port: 1}, {host: 'b', tiemout: 5}]
+}`
+
+	var val struct {
+		Name    string    `json6:"name"`
+		Servers []service `json6:"servers"`
+	}
+
+	if err := NewDecoder(strings.NewReader(input)).Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&val)
+	var fieldErr *UnknownFieldError
+	if !errors.As(err, &fieldErr) {
+		t.Errorf("unexpected %v, expecting *UnknownFieldError", err)
+		return
+	}
+
+	if fieldErr.Key != "tiemout" || fieldErr.Path != "$.servers[1]" || fieldErr.GoType != reflect.TypeOf(service{}) {
+		t.Errorf("unexpected key %s at path %s of type %s", fieldErr.Key, fieldErr.Path, fieldErr.GoType)
+	}
+
+	if fieldErr.Pos.Line() != 3 || fieldErr.Pos.Column() != 46 {
+		t.Errorf("unexpected position %d:%d, expecting %d:%d", fieldErr.Pos.Line(), fieldErr.Pos.Column(), 3, 46)
+	}
+
+	// the first unknown key in source is reported, not the first in alphabetical order
+	dec = NewDecoder(strings.NewReader(`{zone: 'x', host: 'a', alias: 'b'}`))
+	dec.DisallowUnknownFields()
+	var svc service
+	if err := dec.Decode(&svc); !errors.As(err, &fieldErr) || fieldErr.Key != "zone" {
+		t.Errorf("unexpected %v, expecting unknown field zone", err)
+	}
+}
+
+func TestDecoderDuplicateKeys(t *testing.T) {
+	input := `{a: 1, b: 2, a: 3}`
+	policies := []DuplicateKeyPolicy{DuplicateKeysLastWins, DuplicateKeysFirstWins}
+	expects := []int{3, 1}
+	for i, policy := range policies {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.DuplicateKeys(policy)
+		val := make(map[string]int)
+		if err := dec.Decode(&val); err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if val["a"] != expects[i] || val["b"] != 2 {
+			t.Errorf("unexpected %v, expecting a: %d", val, expects[i])
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.DuplicateKeys(DuplicateKeysReject)
+	var val map[string]int
+	err := dec.Decode(&val)
+	var dupErr *DuplicateKeyError
+	if !errors.As(err, &dupErr) {
+		t.Errorf("unexpected %v, expecting *DuplicateKeyError", err)
+		return
+	}
+
+	if dupErr.Key != "a" || dupErr.FirstPos.Column() != 2 || dupErr.Pos.Column() != 14 {
+		t.Errorf("unexpected %s", dupErr.Error())
+	}
+}
+
+func TestDecoderUseNumber(t *testing.T) {
+	input := `{id: 0x7FFF_FFFF_FFFF_FFFF_F, ratio: 0.1000000000000000055511151231257827, list: [1, 2.5]}`
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	var val map[string]interface{}
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	expected := map[string]interface{}{
+		"id":    Number("147573952589676412927"),
+		"ratio": Number("0.1000000000000000055511151231257827"),
+		"list":  []interface{}{Number("1"), Number("2.5")},
+	}
+
+	if !reflect.DeepEqual(val, expected) {
+		t.Errorf("unexpected %#v, expecting %#v", val, expected)
+	}
+}
+
+func TestDecoderArrayHoles(t *testing.T) {
+	input := `['test',,,'one',,]`
+	policies := []ArrayHolePolicy{ArrayHolesKeep, ArrayHolesZero, ArrayHolesSkip}
+	expects := [][]interface{}{
+		{"test", Hole, Hole, "one", Hole},
+		{"test", nil, nil, "one", nil},
+		{"test", "one"},
+	}
+
+	for i, policy := range policies {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.ArrayHoles(policy)
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if !reflect.DeepEqual(val, expects[i]) {
+			t.Errorf("unexpected %#v, expecting %#v", val, expects[i])
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.ArrayHoles(ArrayHolesReject)
+	var val []string
+	err := dec.Decode(&val)
+	var holeErr *ArrayHoleError
+	if !errors.As(err, &holeErr) {
+		t.Errorf("unexpected %v, expecting *ArrayHoleError", err)
+		return
+	}
+
+	if holeErr.Index != 1 || holeErr.Pos.Column() != 9 {
+		t.Errorf("unexpected %s", holeErr.Error())
+	}
+}
+
+func TestDecoderLoneSurrogates(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`'\uD83D\uDE00' '\uD83D!'`))
+	dec.LoneSurrogates(LoneSurrogatesReject)
+	var str string
+	if err := dec.Decode(&str); err != nil || str != "\U0001F600" {
+		t.Errorf("unexpected %q, %v, expecting %q", str, err, "\U0001F600")
+	}
+
+	err := dec.Decode(&str)
+	var synErr *SyntaxError
+	if !errors.As(err, &synErr) || synErr.Pos.Column() != 16 {
+		t.Errorf("unexpected %v, expecting *SyntaxError at 1:16", err)
+	}
+}
+
+func TestDecoderRawCapture(t *testing.T) {
+	large := "[" + strings.Repeat("'item', ", 10000) + "]"
+	dec := NewDecoder(strings.NewReader(large + "1[2, /* two */ 3] {any: [4]}"))
+	var list []string
+	if err := dec.Decode(&list); err != nil || len(list) != 10000 {
+		t.Errorf("unexpected %d items, %v, expecting 10000 items", len(list), err)
+		return
+	}
+
+	// source is not kept when the destination does not need it
+	if n := len(dec.d.src.buf); n > utf8.UTFMax {
+		t.Errorf("unexpected %d bytes of source kept, expecting at most %d", n, utf8.UTFMax)
+	}
+
+	var one int
+	if err := dec.Decode(&one); err != nil || one != 1 {
+		t.Errorf("unexpected %d, %v, expecting 1", one, err)
+		return
+	}
+
+	// "[" is read ahead of the value while decoding 1, it is still kept
+	var node Node
+	if err := dec.Decode(&node); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if expected := "[2, /* two */ 3]"; node.Raw != expected {
+		t.Errorf("unexpected raw %q, expecting %q", node.Raw, expected)
+	}
+
+	// Node held by interface
+	var val struct {
+		Any interface{}
+	}
+
+	val.Any = &node
+	if err := dec.Decode(&val); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if expected := "[4]"; node.Raw != expected {
+		t.Errorf("unexpected raw %q, expecting %q", node.Raw, expected)
+	}
+}
+
+func TestDecoderLoneSurrogatesQuoted(t *testing.T) {
+	var val struct {
+		Name string `json6:"name,string"`
+	}
+
+	dec := NewDecoder(strings.NewReader(`{name: "'\\uD83D!'"}`))
+	dec.LoneSurrogates(LoneSurrogatesReject)
+	var typeErr *UnmarshalTypeError
+	if err := dec.Decode(&val); !errors.As(err, &typeErr) || typeErr.Path != "$.name" {
+		t.Errorf("unexpected %v, %q, expecting *UnmarshalTypeError at $.name", err, val.Name)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{name: "'\\uD83D!'"}`))
+	if err := dec.Decode(&val); err != nil || val.Name != "\uFFFD!" {
+		t.Errorf("unexpected %q, %v, expecting %q", val.Name, err, "\uFFFD!")
+	}
+}
+
+func TestDecoderRaw(t *testing.T) {
+	input := `// first
+	{a: [1, /* one */ 2]} /* between */ [true,
+	'x']`
+
+	expects := []string{"{a: [1, /* one */ 2]}", "[true,\n\t'x']"}
+	dec := NewDecoder(strings.NewReader(input))
+	for _, expected := range expects {
+		var node Node
+		if err := dec.Decode(&node); err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		if node.Raw != expected {
+			t.Errorf("unexpected raw %q, expecting %q", node.Raw, expected)
+		}
+	}
+
+	if raw := dec.d.src.slice(0, 1); raw != nil {
+		t.Errorf("unexpected %q, expecting source before the last value to be discarded", raw)
+	}
+}